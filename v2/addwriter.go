@@ -1,10 +1,14 @@
 package logr
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
 	"sync"
+	"unicode/utf8"
 )
 
 type writerMessage struct {
@@ -12,77 +16,255 @@ type writerMessage struct {
 	w io.Writer
 }
 
+// MessageWriter is implemented by writers that need the Message as well as its formatted output, for example to choose
+// where to write it from its meta data. The listener calls WriteMessage instead of Write for writers implementing it.
+// The Message is reused once WriteMessage returns and must not be retained. p is the output of the Formatter given to
+// AddWriter, which writers that format the Message themselves may ignore, reporting len(p) as written like Write.
+type MessageWriter interface {
+	io.Writer
+	WriteMessage(m *Message, p []byte) (int, error)
+}
+
 var (
 	mutex        = sync.RWMutex{}
 	addWriter    = make(chan writerMessage)
 	removeWriter = make(chan writerMessage)
+
+	namedFormatters = make(map[string]AppendFormatter)
 )
 
 // Formatter is a function that returns a formatted byte array representation of the given
 // Message.
 type Formatter func(m *Message) []byte
 
-// FormatJSON is a Formatter that converts a Message to json
-func FormatJSON(m *Message) []byte {
-	r, err := json.Marshal(m)
-	if err != nil {
-		fmt.Printf("failed to marshal Message in logr.FormatJSON: %v\n", err)
+// AppendFormat implements AppendFormatter, allowing any Formatter to be used where an AppendFormatter is expected.
+func (f Formatter) AppendFormat(dst []byte, m *Message) []byte {
+	return append(dst, f(m)...)
+}
+
+// AppendFormatter appends a formatted representation of the given Message to dst and returns the extended buffer.
+// Writers are given buffers that are reused between messages, so formatting does not need to allocate.
+type AppendFormatter interface {
+	AppendFormat(dst []byte, m *Message) []byte
+}
+
+// AppendFormatterFunc is a function that implements AppendFormatter
+type AppendFormatterFunc func(dst []byte, m *Message) []byte
+
+// AppendFormat implements AppendFormatter
+func (f AppendFormatterFunc) AppendFormat(dst []byte, m *Message) []byte {
+	return f(dst, m)
+}
+
+// builtinFormatters maps the built-in Formatter functions to their AppendFormatter equivalents
+var builtinFormatters = map[uintptr]AppendFormatter{
+	funcPointer(FormatDefault):     AppendFormatterFunc(AppendDefault),
+	funcPointer(FormatWithColours): AppendFormatterFunc(AppendWithColours),
+	funcPointer(FormatJSON):        AppendFormatterFunc(AppendJSON),
+	funcPointer(FormatPretty):      AppendFormatterFunc(AppendPretty),
+}
+
+// funcPointer returns the code pointer of a Formatter
+func funcPointer(f Formatter) uintptr {
+	return reflect.ValueOf(f).Pointer()
+}
+
+// appendFormatterOf returns the AppendFormatter for a Formatter, using the allocation-free equivalent of the
+// built-in Formatters.
+func appendFormatterOf(f Formatter) AppendFormatter {
+	if af, ok := builtinFormatters[funcPointer(f)]; ok {
+		return af
 	}
-	return append(r, []byte("\n")...)
+	return f
+}
+
+// formatterKey returns the identity used to share the formatted output of f between writers, or nil if it cannot be
+// shared. Pointers are identified by their address and functions by their code pointer, unless they are closures or
+// method values whose behaviour depends on captured state.
+func formatterKey(f any) any {
+	rv := reflect.ValueOf(f)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+		return f
+	case reflect.Func:
+		if rv.IsNil() {
+			return nil
+		}
+		fn := runtime.FuncForPC(rv.Pointer())
+		if fn == nil || closureName.MatchString(fn.Name()) {
+			return nil
+		}
+		return rv.Pointer()
+	}
+	return nil
+}
+
+// closureName matches the names the runtime gives to closures and method values
+var closureName = regexp.MustCompile(`(\.func\d+(\.\d+)*|-fm)$`)
+
+// RegisterFormatter registers an AppendFormatter under a name for use with WithNamedFormatter. Writers using the same
+// name share the formatted output of each message, which is useful for formatters that cannot be identified by a
+// pointer, such as closures returned by NewTemplateFormatter.
+func RegisterFormatter(name string, f AppendFormatter) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	namedFormatters[name] = f
 }
 
 // LogrFormat is a Formatter that converts a Message to a default format.
 func FormatDefault(m *Message) []byte {
-	var s string
-	if m.Meta == nil {
-		s = fmt.Sprintf(
-			"%-25s | %s | %s | %s\n",
-			m.Time,
-			m.Code,
-			m.Type.Rune(),
-			m.Desc,
-		)
-	} else {
-		s = fmt.Sprintf(
-			"%-25s | %s | %s | %s | %+v\n",
-			m.Time,
-			m.Code,
-			m.Type.Rune(),
-			m.Desc,
-			m.Meta,
-		)
-	}
-	return []byte(s)
+	return AppendDefault(make([]byte, 0, 128), m)
+}
+
+// AppendDefault is the AppendFormatter equivalent of FormatDefault
+func AppendDefault(dst []byte, m *Message) []byte {
+	dst = appendPadded(dst, m.Time, 25)
+	dst = append(dst, " | "...)
+	dst = append(dst, m.Code...)
+	dst = append(dst, " | "...)
+	dst = append(dst, m.Type.Rune()...)
+	dst = append(dst, " | "...)
+	dst = appendText(dst, m.Desc)
+	if m.Meta != nil {
+		dst = append(dst, " | "...)
+		start := len(dst)
+		dst = appendMetaText(dst, m.Meta)
+		dst = sanitizeFrom(dst, start)
+	}
+	return append(dst, '\n')
 }
 
 // FormatWithColours is a Formatter that converts a Message to a colourful default format.
 func FormatWithColours(m *Message) []byte {
-	var s string
-	if m.Meta == nil {
-		s = fmt.Sprintf(
-			m.Type.Colour()+"%-25s | %s | %s | "+ColourReset+"%s\n",
-			m.Time,
-			m.Code,
-			m.Type.Rune(),
-			m.Desc,
-		)
-	} else {
-		s = fmt.Sprintf(
-			m.Type.Colour()+"%-25s | %s | %s | "+ColourReset+"%s"+m.Type.Colour()+" | %+v"+ColourReset+"\n",
-			m.Time,
-			m.Code,
-			m.Type.Rune(),
-			m.Desc,
-			m.Meta,
-		)
-	}
-
-	return []byte(s)
+	return AppendWithColours(make([]byte, 0, 160), m)
+}
+
+// AppendWithColours is the AppendFormatter equivalent of FormatWithColours
+func AppendWithColours(dst []byte, m *Message) []byte {
+	dst = append(dst, m.Type.Colour()...)
+	dst = appendPadded(dst, m.Time, 25)
+	dst = append(dst, " | "...)
+	dst = append(dst, m.Code...)
+	dst = append(dst, " | "...)
+	dst = append(dst, m.Type.Rune()...)
+	dst = append(dst, " | "...)
+	dst = append(dst, ColourReset...)
+	dst = appendText(dst, m.Desc)
+	if m.Meta != nil {
+		dst = append(dst, m.Type.Colour()...)
+		dst = append(dst, " | "...)
+		start := len(dst)
+		dst = appendMetaText(dst, m.Meta)
+		dst = sanitizeFrom(dst, start)
+		dst = append(dst, ColourReset...)
+	}
+	return append(dst, '\n')
+}
+
+// appendPadded appends s padded with spaces on the right to n characters, like the %-ns verb
+func appendPadded(dst []byte, s string, n int) []byte {
+	dst = append(dst, s...)
+	for c := utf8.RuneCountInString(s); c < n; c++ {
+		dst = append(dst, ' ')
+	}
+	return dst
+}
+
+// appendMetaText appends v the way the %+v verb prints it. Maps with string keys and common value types are written
+// directly and anything else falls back to fmt.
+func appendMetaText(dst []byte, v any) []byte {
+	switch t := v.(type) {
+	case nil:
+		return append(dst, "<nil>"...)
+	case string:
+		return append(dst, t...)
+	case bool:
+		return strconv.AppendBool(dst, t)
+	case int:
+		return strconv.AppendInt(dst, int64(t), 10)
+	case int64:
+		return strconv.AppendInt(dst, t, 10)
+	case uint64:
+		return strconv.AppendUint(dst, t, 10)
+	case float64:
+		return strconv.AppendFloat(dst, t, 'g', -1, 64)
+	case error:
+		return append(dst, errorText(t)...)
+	case fmt.Stringer:
+		return append(dst, stringerText(t)...)
+	case Meta:
+		return appendMetaMapText(dst, t)
+	case MetaData:
+		return appendMetaMapText(dst, t)
+	case map[string]any:
+		return appendMetaMapText(dst, t)
+	default:
+		return append(dst, fmt.Sprintf("%+v", v)...)
+	}
+}
+
+// appendMetaMapText appends m the way the %+v verb prints it
+func appendMetaMapText(dst []byte, m map[string]any) []byte {
+	var arr [16]string
+	dst = append(dst, "map["...)
+	for i, k := range sortedKeys(arr[:0], m) {
+		if i > 0 {
+			dst = append(dst, ' ')
+		}
+		dst = append(dst, k...)
+		dst = append(dst, ':')
+		dst = appendMetaText(dst, m[k])
+	}
+	return append(dst, ']')
+}
+
+// errorText returns the text of err the way the %+v verb prints it. Errors implementing fmt.Formatter are formatted
+// with %+v, so they can add detail such as a stack trace, and a nil pointer is written as <nil> instead of panicking.
+func errorText(err error) (s string) {
+	if isNilPointer(err) {
+		return "<nil>"
+	}
+	if _, ok := err.(fmt.Formatter); ok {
+		return fmt.Sprintf("%+v", err)
+	}
+	defer recoverMethod(&s, err, "Error")
+	return err.Error()
+}
+
+// stringerText returns the text of v, writing a nil pointer as <nil> instead of panicking
+func stringerText(v fmt.Stringer) (s string) {
+	if isNilPointer(v) {
+		return "<nil>"
+	}
+	defer recoverMethod(&s, v, "String")
+	return v.String()
+}
+
+// isNilPointer reports whether v holds a nil pointer, whose methods usually panic
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// recoverMethod replaces the result of a panicking Error or String method with the text fmt prints for it. It must be
+// deferred directly.
+func recoverMethod(s *string, v any, method string) {
+	if r := recover(); r != nil {
+		*s = fmt.Sprintf("%%!v(PANIC=%s method: %v)", method, r)
+	}
 }
 
 type WriterConfig struct {
-	format Formatter
-	filter Type
+	format    AppendFormatter
+	formatKey any
+	filter    Type
+	redactor  *Redactor
+	redactSet bool
+	limits    *Limits
+	limitsSet bool
 }
 
 type WriterConfigModifier func(c WriterConfig) WriterConfig
@@ -91,8 +273,34 @@ type WriterConfigModifier func(c WriterConfig) WriterConfig
 // for a Writer. A custom formatter may be provided to convert the Message to any desired format before it is passed
 // to the Writer.
 func WithFormatter(f Formatter) WriterConfigModifier {
+	return func(oc WriterConfig) WriterConfig {
+		oc.format = appendFormatterOf(f)
+		oc.formatKey = formatterKey(f)
+		return oc
+	}
+}
+
+// WithAppendFormatter creates a WriterConfigModifier like WithFormatter for an AppendFormatter
+func WithAppendFormatter(f AppendFormatter) WriterConfigModifier {
+	return func(oc WriterConfig) WriterConfig {
+		oc.format = f
+		oc.formatKey = formatterKey(f)
+		return oc
+	}
+}
+
+// WithNamedFormatter creates a WriterConfigModifier like WithFormatter for an AppendFormatter registered with
+// RegisterFormatter. It panics if no AppendFormatter is registered under name.
+func WithNamedFormatter(name string) WriterConfigModifier {
+	mutex.RLock()
+	f, ok := namedFormatters[name]
+	mutex.RUnlock()
+	if !ok {
+		panic(fmt.Sprintf("logr formatter `%s` is not registered", name))
+	}
 	return func(oc WriterConfig) WriterConfig {
 		oc.format = f
+		oc.formatKey = "name:" + name
 		return oc
 	}
 }
@@ -105,12 +313,46 @@ func WithFilter(f Type) WriterConfigModifier {
 	}
 }
 
-// AddWriter add a io.Writer to the collection of writers that store the log messages.
+// WithRedactor creates a WriterConfigModifier that redacts messages with r before they are formatted for the Writer,
+// instead of with the Redactor set with SetRedactor. A nil Redactor disables redaction for the Writer.
+func WithRedactor(r *Redactor) WriterConfigModifier {
+	return func(oc WriterConfig) WriterConfig {
+		oc.redactor = r
+		oc.redactSet = true
+		return oc
+	}
+}
+
+// WithLimits creates a WriterConfigModifier that truncates messages to l before they are formatted for the Writer,
+// instead of to the Limits set with SetLimits. Nil Limits disables truncation for the Writer.
+func WithLimits(l *Limits) WriterConfigModifier {
+	return func(oc WriterConfig) WriterConfig {
+		oc.limits = l
+		oc.limitsSet = true
+		return oc
+	}
+}
+
+// pipelineOf returns the steps applied to messages before they are formatted for the writer, given the global ones
+func (oc *WriterConfig) pipelineOf(global pipeline) pipeline {
+	p := global
+	if oc.redactSet {
+		p.redactor = oc.redactor
+	}
+	if oc.limitsSet {
+		p.limits = oc.limits
+	}
+	return p
+}
+
+// AddWriter add a io.Writer to the collection of writers that store the log messages. MessageWriters with a formatter
+// of their own, such as LokiWriter and ElasticWriter, ignore the Formatter set with WithFormatter.
 func AddWriter(w io.Writer, configs ...WriterConfigModifier) (stop func()) {
 	// default config
 	oc := WriterConfig{
-		format: FormatDefault,
-		filter: All,
+		format:    AppendFormatterFunc(AppendDefault),
+		formatKey: funcPointer(FormatDefault),
+		filter:    All,
 	}
 	// apply optional extra config modifiers
 	for _, c := range configs {
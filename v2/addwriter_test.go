@@ -0,0 +1,175 @@
+package logr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func formatTestMessages() []*Message {
+	return []*Message{
+		{Type: I, Time: "Oct 17 2026 14:05:06", Code: "abc123", Desc: "no meta"},
+		{Type: W, Time: "Oct 17 2026 14:05:06.1234", Code: "abc123", Desc: "empty meta", Meta: MetaData{}},
+		{
+			Type: E,
+			Time: "Oct 17 2026 14:05:06",
+			Code: "abc123",
+			Desc: "<b>\"quoted\" & \\   \xff</b>",
+			Meta: MetaData{
+				"string":   "value",
+				"int":      42,
+				"int64":    int64(-7),
+				"uint8":    uint8(7),
+				"float":    3.25,
+				"small":    1e-9,
+				"big":      1e21,
+				"float32":  float32(0.1),
+				"bool":     true,
+				"nil":      nil,
+				"duration": 2 * time.Second,
+				"time":     time.Date(2026, 10, 17, 14, 5, 6, 7, time.UTC),
+				"error":    errors.New("failed"),
+				"type":     W,
+				"nested":   Meta{"a": []any{1, "b", nil}, "b": map[string]string{"c": "d"}},
+				"strings":  []string{"x", "y"},
+				"struct":   struct{ A int }{A: 1},
+			},
+		},
+	}
+}
+
+func TestFormatDefault(t *testing.T) {
+	for _, m := range formatTestMessages() {
+		var want string
+		if m.Meta == nil {
+			want = fmt.Sprintf("%-25s | %s | %s | %s\n", m.Time, m.Code, m.Type.Rune(), SanitizeText(m.Desc))
+		} else {
+			want = fmt.Sprintf("%-25s | %s | %s | %s | %s\n", m.Time, m.Code, m.Type.Rune(), SanitizeText(m.Desc), SanitizeText(fmt.Sprintf("%+v", m.Meta)))
+		}
+		if got := string(FormatDefault(m)); got != want {
+			t.Errorf("FormatDefault() = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestFormatWithColours(t *testing.T) {
+	for _, m := range formatTestMessages() {
+		var want string
+		if m.Meta == nil {
+			want = fmt.Sprintf(m.Type.Colour()+"%-25s | %s | %s | "+ColourReset+"%s\n", m.Time, m.Code, m.Type.Rune(), SanitizeText(m.Desc))
+		} else {
+			want = fmt.Sprintf(m.Type.Colour()+"%-25s | %s | %s | "+ColourReset+"%s"+m.Type.Colour()+" | %s"+ColourReset+"\n", m.Time, m.Code, m.Type.Rune(), SanitizeText(m.Desc), SanitizeText(fmt.Sprintf("%+v", m.Meta)))
+		}
+		if got := string(FormatWithColours(m)); got != want {
+			t.Errorf("FormatWithColours() = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestAppendJSONMatchesEncodingJSON(t *testing.T) {
+	for _, m := range formatTestMessages() {
+		want, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(AppendJSON(nil, m)); got != string(want)+"\n" {
+			t.Errorf("AppendJSON() = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestAppendFormatterAllocations(t *testing.T) {
+	m := &Message{Type: I, Time: "Oct 17 2026 14:05:06", Code: "abc123", Desc: "message", Meta: MetaData{"user": "u1", "count": 3}}
+	buf := make([]byte, 0, 1024)
+	for name, f := range map[string]AppendFormatter{
+		"AppendDefault":     AppendFormatterFunc(AppendDefault),
+		"AppendWithColours": AppendFormatterFunc(AppendWithColours),
+		"AppendJSON":        AppendFormatterFunc(AppendJSON),
+	} {
+		if n := testing.AllocsPerRun(100, func() { buf = f.AppendFormat(buf[:0], m) }); n > 0 {
+			t.Errorf("expected %s not to allocate. Got: %v allocations", name, n)
+		}
+	}
+}
+
+func TestWithFormatter(t *testing.T) {
+	c := WithFormatter(FormatJSON)(WriterConfig{})
+	if _, ok := c.format.(AppendFormatterFunc); !ok {
+		t.Errorf("expected the built-in FormatJSON to use its AppendFormatter. Got: %T", c.format)
+	}
+
+	custom := func(m *Message) []byte { return []byte(m.Desc) }
+	c = WithFormatter(custom)(WriterConfig{})
+	if got := string(c.format.AppendFormat([]byte("prefix "), &Message{Desc: "custom"})); got != "prefix custom" {
+		t.Errorf("expected a Formatter to be adapted to an AppendFormatter. Got: %s", got)
+	}
+}
+
+func BenchmarkAppendJSON(b *testing.B) {
+	m := formatTestMessages()[2]
+	buf := make([]byte, 0, 4096)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = AppendJSON(buf[:0], m)
+	}
+}
+
+func BenchmarkAppendDefault(b *testing.B) {
+	m := formatTestMessages()[0]
+	buf := make([]byte, 0, 4096)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = AppendDefault(buf[:0], m)
+	}
+}
+
+// nilError is an error whose Error method panics on a nil pointer
+type nilError struct {
+	msg string
+}
+
+func (e *nilError) Error() string {
+	return e.msg
+}
+
+// stackError is an error that adds detail when formatted with %+v
+type stackError struct{}
+
+func (stackError) Error() string { return "failed" }
+
+func (e stackError) Format(s fmt.State, verb rune) {
+	if s.Flag('+') {
+		fmt.Fprint(s, "failed\nstack")
+		return
+	}
+	fmt.Fprint(s, e.Error())
+}
+
+func TestFormattersNilPointers(t *testing.T) {
+	var u *url.URL
+	var err *nilError
+	m := &Message{Type: E, Code: "abc123", Desc: "nil values", Meta: MetaData{"url": u, "error": err, "stack": stackError{}}}
+
+	formatters := map[string]Formatter{"default": FormatDefault, "logfmt": FormatLogfmt, "pretty": FormatPretty}
+	for name, f := range formatters {
+		b := f(m)
+		if !bytes.Contains(b, []byte("<nil>")) {
+			t.Errorf("expected %s to write nil pointers as <nil>. Got: %s", name, b)
+		}
+		if !bytes.Contains(b, []byte("stack")) {
+			t.Errorf("expected %s to format fmt.Formatter errors with %%+v. Got: %s", name, b)
+		}
+	}
+
+	wbuf := &bytes.Buffer{}
+	defer AddWriter(wbuf)()
+	With(Meta{"url": u, "error": err}).Error("TestFormattersNilPointers")
+	Wait()
+	if b := mustReadBuffer(wbuf, t); !bytes.Contains(b, []byte("error:<nil> url:<nil>")) {
+		t.Errorf("expected the listener to write nil pointers as <nil>. Got: %s", b)
+	}
+}
@@ -0,0 +1,560 @@
+package logr
+
+import (
+	"compress/gzip"
+	"container/list"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ArchiveConfig struct {
+	template     string
+	utc          bool
+	idleTimeout  time.Duration
+	maxOpen      int
+	retention    time.Duration
+	maxTotalSize int64
+	sweepEvery   time.Duration
+}
+
+type ArchiveConfigModifier func(c ArchiveConfig) ArchiveConfig
+
+// WithPartitionTemplate creates an ArchiveConfigModifier that sets the path of the partition a message is written to,
+// relative to the archive directory. %Y, %m, %d, %H and %M are replaced with the year, month, day, hour and minute
+// the message was logged, %% with a percent sign and {key} with the value of a meta data key, or "unknown" if it is
+// not set. Characters other than letters, digits, dots, dashes and underscores in meta data values are replaced with
+// underscores. Partitions ending in .gz are compressed with gzip. The default is %Y/%m/%d/%H.log.
+func WithPartitionTemplate(template string) ArchiveConfigModifier {
+	return func(c ArchiveConfig) ArchiveConfig {
+		c.template = template
+		return c
+	}
+}
+
+// WithPartitionUTC creates an ArchiveConfigModifier that partitions messages by the UTC time they were logged,
+// instead of local time
+func WithPartitionUTC() ArchiveConfigModifier {
+	return func(c ArchiveConfig) ArchiveConfig {
+		c.utc = true
+		return c
+	}
+}
+
+// WithIdleTimeout creates an ArchiveConfigModifier that closes partitions that have not been written to for d. The
+// default is 5 minutes and zero keeps partitions open until they are closed to stay within WithMaxOpenFiles.
+func WithIdleTimeout(d time.Duration) ArchiveConfigModifier {
+	return func(c ArchiveConfig) ArchiveConfig {
+		c.idleTimeout = d
+		return c
+	}
+}
+
+// WithMaxOpenFiles creates an ArchiveConfigModifier that sets the maximum number of partitions kept open. The least
+// recently written partition is closed to open another. The default is 64.
+func WithMaxOpenFiles(n int) ArchiveConfigModifier {
+	return func(c ArchiveConfig) ArchiveConfig {
+		c.maxOpen = n
+		return c
+	}
+}
+
+// WithRetentionDays creates an ArchiveConfigModifier that deletes partitions last written more than n days ago
+func WithRetentionDays(n int) ArchiveConfigModifier {
+	return func(c ArchiveConfig) ArchiveConfig {
+		c.retention = time.Duration(n) * 24 * time.Hour
+		return c
+	}
+}
+
+// WithMaxTotalSize creates an ArchiveConfigModifier that deletes the oldest partitions while the archive is larger
+// than n bytes
+func WithMaxTotalSize(n int64) ArchiveConfigModifier {
+	return func(c ArchiveConfig) ArchiveConfig {
+		c.maxTotalSize = n
+		return c
+	}
+}
+
+// WithSweepInterval creates an ArchiveConfigModifier that sets how often partitions are checked against the retention
+// and total size limits, in addition to when the ArchiveWriter is created. The default is one hour and zero only
+// sweeps when the ArchiveWriter is created.
+func WithSweepInterval(d time.Duration) ArchiveConfigModifier {
+	return func(c ArchiveConfig) ArchiveConfig {
+		c.sweepEvery = d
+		return c
+	}
+}
+
+// partitionSegment is a part of a partition template: literal text, a time verb or a meta data key
+type partitionSegment struct {
+	text string
+	verb byte
+	key  string
+}
+
+// partition is an open partition file
+type partition struct {
+	path string
+	file *os.File
+	gz   *gzip.Writer
+	last time.Time
+	elem *list.Element
+}
+
+// write writes p to the partition
+func (p *partition) write(b []byte) (int, error) {
+	if p.gz != nil {
+		return p.gz.Write(b)
+	}
+	return p.file.Write(b)
+}
+
+// flush writes any data buffered by the compressor
+func (p *partition) flush() error {
+	if p.gz != nil {
+		if err := p.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	return p.file.Sync()
+}
+
+// close closes the partition file
+func (p *partition) close() error {
+	var err error
+	if p.gz != nil {
+		err = p.gz.Close()
+	}
+	if cerr := p.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ArchiveWriter writes messages to files partitioned by the time they were logged and by meta data, for example
+// logs/2026/10/17/acme/app-14.jsonl.gz. Idle partitions are closed, the number of open files is capped and a sweeper
+// deletes partitions beyond the retention period or total size. It implements MessageWriter, Flusher and io.Closer.
+type ArchiveWriter struct {
+	c        ArchiveConfig
+	dir      string
+	segments []partitionSegment
+	pattern  *regexp.Regexp
+	loc      *time.Location
+	now      func() time.Time
+
+	mu     sync.Mutex
+	open   map[string]*partition
+	lru    *list.List
+	closed bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewArchiveWriter creates an ArchiveWriter that writes partitions under dir
+//
+//	w, err := logr.NewArchiveWriter("logs", logr.WithPartitionTemplate("%Y/%m/%d/{tenant}/app-%H.jsonl.gz"),
+//		logr.WithRetentionDays(400))
+//	...
+//	logr.AddWriter(w, logr.WithFormatter(logr.FormatJSON))
+func NewArchiveWriter(dir string, configs ...ArchiveConfigModifier) (*ArchiveWriter, error) {
+	// default config
+	c := ArchiveConfig{
+		template:    "%Y/%m/%d/%H.log",
+		idleTimeout: 5 * time.Minute,
+		maxOpen:     64,
+		sweepEvery:  time.Hour,
+	}
+	// apply optional extra config modifiers
+	for _, m := range configs {
+		c = m(c)
+	}
+
+	segments, err := parsePartitionTemplate(c.template)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("logr: failed to create archive directory: %w", err)
+	}
+	w := &ArchiveWriter{
+		c:        c,
+		dir:      dir,
+		segments: segments,
+		pattern:  partitionPattern(segments),
+		loc:      time.Local,
+		now:      time.Now,
+		open:     map[string]*partition{},
+		lru:      list.New(),
+		stop:     make(chan struct{}),
+	}
+	if c.utc {
+		w.loc = time.UTC
+	}
+	w.wg.Add(1)
+	go w.maintain()
+	return w, nil
+}
+
+// Write writes p to the partition for the current time, with no meta data
+func (w *ArchiveWriter) Write(p []byte) (int, error) {
+	return w.WriteMessage(&Message{Timestamp: w.now()}, p)
+}
+
+// WriteMessage implements MessageWriter. It writes p to the partition for m.
+func (w *ArchiveWriter) WriteMessage(m *Message, p []byte) (int, error) {
+	path := w.partitionPath(m)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	part, err := w.partition(path)
+	if err != nil {
+		return 0, err
+	}
+	part.last = w.now()
+	w.lru.MoveToFront(part.elem)
+	return part.write(p)
+}
+
+// Flush implements Flusher. It writes any compressed data still buffered and commits the open partitions to stable
+// storage.
+func (w *ArchiveWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var first error
+	for _, p := range w.open {
+		if err := p.flush(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Close closes the open partitions and stops closing idle partitions and sweeping. Writes after Close return
+// os.ErrClosed.
+func (w *ArchiveWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	var first error
+	for _, p := range w.open {
+		if err := w.closePartition(p); err != nil && first == nil {
+			first = err
+		}
+	}
+	w.mu.Unlock()
+	close(w.stop)
+	w.wg.Wait()
+	return first
+}
+
+// partition returns the open partition at path, opening it and closing the least recently written partition if
+// there are too many open
+func (w *ArchiveWriter) partition(path string) (*partition, error) {
+	if p, ok := w.open[path]; ok {
+		return p, nil
+	}
+	for w.c.maxOpen > 0 && len(w.open) >= w.c.maxOpen {
+		if err := w.closePartition(w.lru.Back().Value.(*partition)); err != nil {
+			Errorf("failed to close archive partition: %v", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("logr: failed to create archive partition directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("logr: failed to open archive partition: %w", err)
+	}
+	p := &partition{path: path, file: f}
+	if strings.HasSuffix(path, ".gz") {
+		// reopened partitions get another gzip member, which gzip readers concatenate
+		p.gz = gzip.NewWriter(f)
+	}
+	p.elem = w.lru.PushFront(p)
+	w.open[path] = p
+	return p, nil
+}
+
+// closePartition closes a partition and forgets it
+func (w *ArchiveWriter) closePartition(p *partition) error {
+	delete(w.open, p.path)
+	w.lru.Remove(p.elem)
+	return p.close()
+}
+
+// partitionPath returns the path of the partition for m
+func (w *ArchiveWriter) partitionPath(m *Message) string {
+	name := expandTemplate(w.segments, messageTime(m).In(w.loc), m.Meta, partitionName)
+	return filepath.Join(w.dir, filepath.FromSlash(name))
+}
+
+// expandTemplate fills in the segments of a template with t and the meta data, using name to make meta data values
+// safe. Missing meta data keys are written as unknown.
+func expandTemplate(segments []partitionSegment, t time.Time, meta MetaData, name func(string) string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		switch {
+		case s.key != "":
+			v, ok := meta[s.key]
+			if !ok {
+				b.WriteString("unknown")
+				continue
+			}
+			b.WriteString(name(string(appendMetaText(nil, v))))
+		case s.verb != 0:
+			switch s.verb {
+			case 'Y':
+				b.WriteString(strconv.Itoa(t.Year()))
+			case 'm':
+				b.WriteString(twoDigits(int(t.Month())))
+			case 'd':
+				b.WriteString(twoDigits(t.Day()))
+			case 'H':
+				b.WriteString(twoDigits(t.Hour()))
+			case 'M':
+				b.WriteString(twoDigits(t.Minute()))
+			}
+		default:
+			b.WriteString(s.text)
+		}
+	}
+	return b.String()
+}
+
+// closeIdle closes the partitions not written to since the idle timeout
+func (w *ArchiveWriter) closeIdle(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for e := w.lru.Back(); e != nil; {
+		p := e.Value.(*partition)
+		if now.Sub(p.last) < w.c.idleTimeout {
+			break
+		}
+		e = e.Prev()
+		if err := w.closePartition(p); err != nil {
+			Errorf("failed to close archive partition: %v", err)
+		}
+	}
+}
+
+// archiveFile is a partition file found by the sweeper
+type archiveFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// sweep deletes the partitions last written before the retention period, then the oldest partitions while the archive
+// is larger than the total size, and then the directories they leave empty. Only files matching the partition
+// template are considered, and open partitions are not deleted.
+func (w *ArchiveWriter) sweep(now time.Time) error {
+	if !w.sweeps() {
+		return nil
+	}
+	var files []archiveFile
+	err := filepath.WalkDir(w.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if rel, err := filepath.Rel(w.dir, path); err != nil || !w.pattern.MatchString(filepath.ToSlash(rel)) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		files = append(files, archiveFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	// partitions are opened with the lock held, so holding it while deleting keeps a partition or its directory from
+	// being deleted as it is opened
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	dirs := map[string]bool{}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	for _, f := range files {
+		expired := w.c.retention > 0 && now.Sub(f.modTime) > w.c.retention
+		oversize := w.c.maxTotalSize > 0 && total > w.c.maxTotalSize
+		if !expired && !oversize {
+			break
+		}
+		if _, ok := w.open[f.path]; ok {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		total -= f.size
+		for d := filepath.Dir(f.path); d != w.dir && strings.HasPrefix(d, w.dir); d = filepath.Dir(d) {
+			dirs[d] = true
+		}
+	}
+
+	// remove the deepest directories first so their parents can be removed when they become empty
+	empty := make([]string, 0, len(dirs))
+	for d := range dirs {
+		empty = append(empty, d)
+	}
+	sort.Slice(empty, func(i, j int) bool {
+		return len(empty[i]) > len(empty[j])
+	})
+	for _, d := range empty {
+		os.Remove(d)
+	}
+	return nil
+}
+
+// sweeps reports whether there is a retention period or total size to sweep partitions for
+func (w *ArchiveWriter) sweeps() bool {
+	return w.c.retention > 0 || w.c.maxTotalSize > 0
+}
+
+// maintain closes idle partitions and runs the sweeper until the ArchiveWriter is closed
+func (w *ArchiveWriter) maintain() {
+	defer w.wg.Done()
+	if w.sweeps() {
+		if err := w.sweep(w.now()); err != nil {
+			Errorf("failed to sweep log archive: %v", err)
+		}
+	}
+	var idle, sweep <-chan time.Time
+	if w.c.idleTimeout > 0 {
+		t := time.NewTicker(w.c.idleTimeout / 2)
+		defer t.Stop()
+		idle = t.C
+	}
+	if w.c.sweepEvery > 0 && w.sweeps() {
+		t := time.NewTicker(w.c.sweepEvery)
+		defer t.Stop()
+		sweep = t.C
+	}
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-idle:
+			w.closeIdle(w.now())
+		case <-sweep:
+			if err := w.sweep(w.now()); err != nil {
+				Errorf("failed to sweep log archive: %v", err)
+			}
+		}
+	}
+}
+
+// partitionPattern returns a regular expression matching the slash separated paths, relative to the archive
+// directory, of the partitions a template can produce
+func partitionPattern(segments []partitionSegment) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, s := range segments {
+		switch {
+		case s.key != "":
+			b.WriteString(`[A-Za-z0-9._-]+`)
+		case s.verb == 'Y':
+			b.WriteString(`\d{4,}`)
+		case s.verb != 0:
+			b.WriteString(`\d{2}`)
+		default:
+			b.WriteString(regexp.QuoteMeta(s.text))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// parsePartitionTemplate splits a partition template into segments
+func parsePartitionTemplate(template string) ([]partitionSegment, error) {
+	var segments []partitionSegment
+	var text strings.Builder
+	literal := func() {
+		if text.Len() > 0 {
+			segments = append(segments, partitionSegment{text: text.String()})
+			text.Reset()
+		}
+	}
+	for i := 0; i < len(template); i++ {
+		switch c := template[i]; c {
+		case '%':
+			if i+1 >= len(template) {
+				return nil, fmt.Errorf("logr: partition template %q ends with %%", template)
+			}
+			i++
+			switch v := template[i]; v {
+			case '%':
+				text.WriteByte('%')
+			case 'Y', 'm', 'd', 'H', 'M':
+				literal()
+				segments = append(segments, partitionSegment{verb: v})
+			default:
+				return nil, fmt.Errorf("logr: unknown verb %%%c in partition template %q", v, template)
+			}
+		case '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 2 {
+				return nil, fmt.Errorf("logr: invalid meta data key in partition template %q", template)
+			}
+			literal()
+			segments = append(segments, partitionSegment{key: template[i+1 : i+end]})
+			i += end
+		default:
+			text.WriteByte(c)
+		}
+	}
+	literal()
+	return segments, nil
+}
+
+// partitionName makes a meta data value safe to use as part of a path
+func partitionName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '-' || c == '_') {
+			b[i] = '_'
+		}
+	}
+	s = string(b)
+	if s == "" || s == "." || s == ".." {
+		return "_"
+	}
+	return s
+}
+
+// twoDigits formats n with a leading zero if needed
+func twoDigits(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
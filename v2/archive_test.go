@@ -0,0 +1,217 @@
+package logr
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePartitionTemplate(t *testing.T) {
+	w := &ArchiveWriter{dir: "logs", loc: time.UTC}
+	var err error
+	if w.segments, err = parsePartitionTemplate("%Y/%m/%d/{tenant}/app-%H%M-100%%.jsonl.gz"); err != nil {
+		t.Fatal(err)
+	}
+	m := &Message{
+		Timestamp: time.Date(2026, 10, 7, 4, 5, 0, 0, time.UTC),
+		Meta:      MetaData{"tenant": "../acme corp"},
+	}
+	want := filepath.Join("logs", "2026", "10", "07", ".._acme_corp", "app-0405-100%.jsonl.gz")
+	if got := w.partitionPath(m); got != want {
+		t.Errorf("expected %s. Got: %s", want, got)
+	}
+	m.Meta = nil
+	want = filepath.Join("logs", "2026", "10", "07", "unknown", "app-0405-100%.jsonl.gz")
+	if got := w.partitionPath(m); got != want {
+		t.Errorf("expected %s. Got: %s", want, got)
+	}
+
+	for _, tmpl := range []string{"%Y/%q.log", "app-%", "{}.log", "{tenant.log"} {
+		if _, err := parsePartitionTemplate(tmpl); err == nil {
+			t.Errorf("expected an error for %q", tmpl)
+		}
+	}
+}
+
+func TestArchiveWriterPartitions(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewArchiveWriter(dir, WithPartitionTemplate("%Y/%m/%d/{tenant}/app-%H.jsonl.gz"), WithPartitionUTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 10, 17, 14, 30, 0, 0, time.UTC)
+	for _, m := range []*Message{
+		{Timestamp: at, Meta: MetaData{"tenant": "acme"}},
+		{Timestamp: at.Add(time.Minute), Meta: MetaData{"tenant": "acme"}},
+		{Timestamp: at, Meta: MetaData{"tenant": "globex"}},
+		{Timestamp: at.Add(time.Hour), Meta: MetaData{"tenant": "acme"}},
+	} {
+		line := m.Timestamp.Format("15:04") + " " + m.Meta["tenant"].(string) + "\n"
+		if _, err := w.WriteMessage(m, []byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]string{
+		"2026/10/17/acme/app-14.jsonl.gz":   "14:30 acme\n14:31 acme\n",
+		"2026/10/17/acme/app-15.jsonl.gz":   "15:30 acme\n",
+		"2026/10/17/globex/app-14.jsonl.gz": "14:30 globex\n",
+	} {
+		if got := readGzip(t, filepath.Join(dir, filepath.FromSlash(path))); got != want {
+			t.Errorf("expected %s to hold %q. Got: %q", path, want, got)
+		}
+	}
+	if _, err := w.Write([]byte("late\n")); err != os.ErrClosed {
+		t.Errorf("expected writes after Close to fail. Got: %v", err)
+	}
+}
+
+func TestArchiveWriterOpenFiles(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewArchiveWriter(dir, WithPartitionTemplate("{n}.log.gz"), WithMaxOpenFiles(2), WithIdleTimeout(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	now, advance := fakeClock(time.Date(2026, 10, 17, 14, 30, 0, 0, time.UTC))
+	w.now = now
+
+	write := func(n string) {
+		if _, err := w.WriteMessage(&Message{Meta: MetaData{"n": n}}, []byte(n+"\n")); err != nil {
+			t.Fatal(err)
+		}
+		advance(time.Second)
+	}
+	write("a")
+	write("b")
+	write("a")
+	write("c")
+	if _, ok := w.open[filepath.Join(dir, "b.log.gz")]; ok || len(w.open) != 2 {
+		t.Errorf("expected the least recently written partition to be closed. Got: %v", w.open)
+	}
+	// reopening a closed partition appends another gzip member
+	write("b")
+	if got := readGzip(t, filepath.Join(dir, "a.log.gz")); got != "a\na\n" {
+		t.Errorf("expected a closed partition to be complete. Got: %q", got)
+	}
+
+	advance(58 * time.Second)
+	w.closeIdle(now())
+	if len(w.open) != 1 {
+		t.Errorf("expected idle partitions to be closed. Got: %v", w.open)
+	}
+	w.Close()
+	if got := readGzip(t, filepath.Join(dir, "b.log.gz")); got != "b\nb\n" {
+		t.Errorf("expected both gzip members to be read. Got: %q", got)
+	}
+}
+
+func TestArchiveWriterSweep(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for path, age := range map[string]time.Duration{
+		"2025/01/01/00.log":    500 * 24 * time.Hour,
+		"2025/01/01/notes.txt": 500 * 24 * time.Hour,
+		"other/00.log":         500 * 24 * time.Hour,
+		"2026/09/01/12.log":    40 * 24 * time.Hour,
+		"2026/10/01/08.log":    10 * 24 * time.Hour,
+		"2026/10/17/14.log":    time.Hour,
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(strings.Repeat("x", 100)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := NewArchiveWriter(dir, WithRetentionDays(400), WithMaxTotalSize(250), WithSweepInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.sweep(now); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if path != dir {
+			rel, _ := filepath.Rel(dir, path)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	// files that are not partitions are left alone, along with their directories
+	want := "2025,2025/01,2025/01/01,2025/01/01/notes.txt,2026,2026/10,2026/10/01,2026/10/01/08.log,2026/10/17," +
+		"2026/10/17/14.log,other,other/00.log"
+	if strings.Join(got, ",") != want {
+		t.Errorf("expected %s. Got: %s", want, strings.Join(got, ","))
+	}
+}
+
+func TestPartitionPattern(t *testing.T) {
+	segments, err := parsePartitionTemplate("%Y/%m/%d/{tenant}/app-%H.jsonl.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := partitionPattern(segments)
+	for path, want := range map[string]bool{
+		"2026/10/17/acme/app-14.jsonl.gz":    true,
+		"2026/10/17/unknown/app-00.jsonl.gz": true,
+		"2026/10/17/acme/app-14.jsonl":       false,
+		"2026/10/17/acme/other-14.jsonl.gz":  false,
+		"2026/10/17/a/b/app-14.jsonl.gz":     false,
+		"2026/10/17/acme/app-14xjsonl.gz":    false,
+	} {
+		if got := re.MatchString(path); got != want {
+			t.Errorf("expected %s to match %v", path, want)
+		}
+	}
+}
+
+func TestArchiveWriterReceivesMessages(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewArchiveWriter(dir, WithPartitionTemplate("{archive_test}.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	stop := AddWriter(w, WithFormatter(FormatJSON))
+	defer stop()
+
+	With(Meta{"archive_test": "tenant-1"}).Info("TestArchiveWriterReceivesMessages")
+	Wait()
+	if b, _ := os.ReadFile(filepath.Join(dir, "tenant-1.log")); !strings.Contains(string(b), "TestArchiveWriterReceivesMessages") {
+		t.Errorf("expected the message to be partitioned by its meta data. Got: %q", b)
+	}
+}
+
+// readGzip returns the uncompressed content of a gzip file
+func readGzip(t *testing.T, path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,232 @@
+package logr
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// ColourBold code
+	ColourBold = "\x1B[1m"
+)
+
+// Theme defines the colours used by a ColourFormatter
+type Theme struct {
+	// Types holds the colour of each Type. Types without a colour use the colour of None.
+	Types map[Type]string
+	// MetaKey is the colour of meta data keys
+	MetaKey string
+	// MetaValue is the colour of meta data values
+	MetaValue string
+	// Bold is added to the colour of the Type rune
+	Bold string
+}
+
+// Available themes
+var (
+	// Theme256 uses the 256-colour palette. It has the same Type colours as FormatWithColours.
+	Theme256 = Theme{
+		Types:     copyTypeColours(typeColourMap),
+		MetaKey:   "\x1B[38;5;245m",
+		MetaValue: "\x1B[38;5;250m",
+		Bold:      ColourBold,
+	}
+	// Theme16 uses the 16 standard colours supported by almost every terminal
+	Theme16 = Theme{
+		Types: map[Type]string{
+			None: "\x1B[0m",
+			P:    "\x1B[91m",
+			E:    "\x1B[31m",
+			W:    "\x1B[33m",
+			I:    "\x1B[34m",
+			D:    "\x1B[36m",
+			S:    "\x1B[32m",
+		},
+		MetaKey:   "\x1B[90m",
+		MetaValue: "\x1B[37m",
+		Bold:      ColourBold,
+	}
+	// ThemeTrueColour uses 24-bit colours
+	ThemeTrueColour = Theme{
+		Types: map[Type]string{
+			None: "\x1B[0m",
+			P:    "\x1B[38;2;215;0;0m",
+			E:    "\x1B[38;2;175;0;0m",
+			W:    "\x1B[38;2;255;135;0m",
+			I:    "\x1B[38;2;0;135;255m",
+			D:    "\x1B[38;2;175;215;255m",
+			S:    "\x1B[38;2;0;175;0m",
+		},
+		MetaKey:   "\x1B[38;2;138;138;138m",
+		MetaValue: "\x1B[38;2;188;188;188m",
+		Bold:      ColourBold,
+	}
+)
+
+// copyTypeColours returns a copy of the Type colours, so changing a theme leaves the others alone
+func copyTypeColours(colours map[Type]string) map[Type]string {
+	c := make(map[Type]string, len(colours))
+	for t, s := range colours {
+		c[t] = s
+	}
+	return c
+}
+
+// Colour returns the colour of the given Type
+func (th Theme) Colour(t Type) string {
+	if s, ok := th.Types[t]; ok {
+		return s
+	}
+	return th.Types[None]
+}
+
+// ColourMode defines when a ColourFormatter writes colours
+type ColourMode int
+
+// Available colour modes
+const (
+	ColourAuto   ColourMode = iota // colour if FORCE_COLOR is set, or if NO_COLOR is not set and the writer is a terminal
+	ColourAlways                   // always colour
+	ColourNever                    // never colour
+)
+
+type ColourConfig struct {
+	theme Theme
+	mode  ColourMode
+}
+
+type ColourConfigModifier func(c ColourConfig) ColourConfig
+
+// WithTheme creates a ColourConfigModifier that sets the theme of a ColourFormatter. The default is Theme256.
+func WithTheme(t Theme) ColourConfigModifier {
+	return func(c ColourConfig) ColourConfig {
+		c.theme = t
+		return c
+	}
+}
+
+// WithColourMode creates a ColourConfigModifier that sets when a ColourFormatter writes colours. The default is
+// ColourAuto.
+func WithColourMode(m ColourMode) ColourConfigModifier {
+	return func(c ColourConfig) ColourConfig {
+		c.mode = m
+		return c
+	}
+}
+
+// ColourFormatter converts a Message to the default format, with colours when the writer it is created for supports
+// them. Without colours the output is the same as FormatDefault.
+type ColourFormatter struct {
+	theme  Theme
+	colour bool
+}
+
+// NewColourFormatter creates a ColourFormatter for w. With ColourAuto colours are written when the FORCE_COLOR
+// environment variable is set, or when NO_COLOR is not set and w is a terminal.
+//
+//	AddWriter(os.Stdout, WithAppendFormatter(NewColourFormatter(os.Stdout, WithTheme(Theme16))))
+func NewColourFormatter(w io.Writer, configs ...ColourConfigModifier) *ColourFormatter {
+	// default config
+	c := ColourConfig{
+		theme: Theme256,
+	}
+	// apply optional extra config modifiers
+	for _, m := range configs {
+		c = m(c)
+	}
+
+	f := &ColourFormatter{theme: c.theme}
+	switch c.mode {
+	case ColourAlways:
+		f.colour = true
+	case ColourNever:
+		f.colour = false
+	default:
+		f.colour = colourEnabled(w)
+	}
+	return f
+}
+
+// Colour reports whether the ColourFormatter writes colours
+func (f *ColourFormatter) Colour() bool {
+	return f.colour
+}
+
+// Format implements Formatter
+func (f *ColourFormatter) Format(m *Message) []byte {
+	return f.AppendFormat(make([]byte, 0, 160), m)
+}
+
+// AppendFormat implements AppendFormatter
+func (f *ColourFormatter) AppendFormat(dst []byte, m *Message) []byte {
+	if !f.colour {
+		return AppendDefault(dst, m)
+	}
+	th := f.theme
+	c := th.Colour(m.Type)
+	dst = append(dst, c...)
+	dst = appendPadded(dst, m.Time, 25)
+	dst = append(dst, " | "...)
+	dst = append(dst, m.Code...)
+	dst = append(dst, " | "...)
+	dst = append(dst, th.Bold...)
+	dst = append(dst, m.Type.Rune()...)
+	dst = append(dst, ColourReset...)
+	dst = append(dst, c...)
+	dst = append(dst, " | "...)
+	dst = append(dst, ColourReset...)
+	dst = appendText(dst, m.Desc)
+	if m.Meta != nil {
+		dst = append(dst, c...)
+		dst = append(dst, " | map["...)
+		var arr [16]string
+		for i, k := range sortedKeys(arr[:0], m.Meta) {
+			if i > 0 {
+				dst = append(dst, ' ')
+			}
+			dst = append(dst, th.MetaKey...)
+			dst = appendText(dst, k)
+			dst = append(dst, ColourReset...)
+			dst = append(dst, ':')
+			dst = append(dst, th.MetaValue...)
+			start := len(dst)
+			dst = appendMetaText(dst, m.Meta[k])
+			dst = sanitizeFrom(dst, start)
+			dst = append(dst, ColourReset...)
+		}
+		dst = append(dst, c...)
+		dst = append(dst, ']')
+		dst = append(dst, ColourReset...)
+	}
+	return append(dst, '\n')
+}
+
+// colourEnabled reports whether colours should be written to w, based on the environment and whether w is a terminal
+func colourEnabled(w io.Writer) bool {
+	if v, ok := os.LookupEnv("FORCE_COLOR"); ok {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "0", "false", "no", "off":
+			return false
+		default:
+			return true
+		}
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(w)
+}
+
+// isTerminal reports whether w is a file connected to a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
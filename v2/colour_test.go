@@ -0,0 +1,64 @@
+package logr
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestNewColourFormatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		configs []ColourConfigModifier
+		want    bool
+	}{
+		{name: "Not a terminal", want: false},
+		{name: "Forced", env: map[string]string{"FORCE_COLOR": "1"}, want: true},
+		{name: "Forced off", env: map[string]string{"FORCE_COLOR": "0"}, want: false},
+		{name: "Forced over NO_COLOR", env: map[string]string{"FORCE_COLOR": "1", "NO_COLOR": "1"}, want: true},
+		{name: "NO_COLOR", env: map[string]string{"NO_COLOR": "1"}, want: false},
+		{name: "Always", env: map[string]string{"NO_COLOR": "1"}, configs: []ColourConfigModifier{WithColourMode(ColourAlways)}, want: true},
+		{name: "Never", env: map[string]string{"FORCE_COLOR": "1"}, configs: []ColourConfigModifier{WithColourMode(ColourNever)}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"FORCE_COLOR", "NO_COLOR"} {
+				t.Setenv(k, "")
+				os.Unsetenv(k)
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if got := NewColourFormatter(&bytes.Buffer{}, tt.configs...).Colour(); got != tt.want {
+				t.Errorf("Colour() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColourFormatter(t *testing.T) {
+	m := &Message{Type: W, Time: "Oct 17 2026 14:05:06", Code: "abc123", Desc: "message", Meta: MetaData{"user": "u1"}}
+
+	plain := NewColourFormatter(&bytes.Buffer{}, WithColourMode(ColourNever))
+	if got, want := string(plain.Format(m)), string(FormatDefault(m)); got != want {
+		t.Errorf("expected uncoloured output to match FormatDefault. Got: %q, want %q", got, want)
+	}
+
+	th := Theme16
+	coloured := NewColourFormatter(&bytes.Buffer{}, WithColourMode(ColourAlways), WithTheme(th))
+	want := th.Colour(W) + "Oct 17 2026 14:05:06      | abc123 | " + ColourBold + "W" + ColourReset + th.Colour(W) + " | " + ColourReset + "message" +
+		th.Colour(W) + " | map[" + th.MetaKey + "user" + ColourReset + ":" + th.MetaValue + "u1" + ColourReset + th.Colour(W) + "]" + ColourReset + "\n"
+	if got := string(coloured.Format(m)); got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
+
+func TestTheme256OwnsItsColours(t *testing.T) {
+	colour := Theme256.Types[E]
+	defer func() { Theme256.Types[E] = colour }()
+	Theme256.Types[E] = "\x1B[38;5;1m"
+	if E.Colour() != colour {
+		t.Errorf("expected changing Theme256 to leave the Type colours alone. Got: %q", E.Colour())
+	}
+}
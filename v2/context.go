@@ -1,19 +1,107 @@
 package logr
 
-import "context"
+import (
+	"context"
+	"sync"
+)
 
-const ContextKey = "logr.Logger"
+// contextKey is the type of the keys logr stores values under in a context
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	metaKey
+	traceKey
+)
+
+// ContextExtractor returns meta data found in a context, such as request or trace identifiers
+type ContextExtractor func(ctx context.Context) Meta
+
+// extractor is a registered ContextExtractor, referenced by pointer so it can be unregistered
+type extractor struct {
+	e ContextExtractor
+}
+
+var (
+	extractorMutex = sync.RWMutex{}
+	extractors     []*extractor
+)
+
+// RegisterContextExtractor adds a ContextExtractor that is run every time a message is logged with a context, through
+// Ctx or one of the ...Ctx methods. Meta data returned by later extractors replaces keys returned by earlier ones.
+// Calling unregister removes it again.
+func RegisterContextExtractor(e ContextExtractor) (unregister func()) {
+	x := &extractor{e: e}
+	extractorMutex.Lock()
+	defer extractorMutex.Unlock()
+	extractors = append(extractors, x)
+
+	return func() {
+		extractorMutex.Lock()
+		defer extractorMutex.Unlock()
+		// build a new slice so the slices extract is iterating over are left untouched
+		kept := make([]*extractor, 0, len(extractors))
+		for _, r := range extractors {
+			if r != x {
+				kept = append(kept, r)
+			}
+		}
+		extractors = kept
+	}
+}
+
+// extract returns a copy of meta with the context meta data and the results of all registered extractors added
+func extract(ctx context.Context, meta Meta) Meta {
+	meta = meta.Copy()
+	for k, v := range MetaFromContext(ctx) {
+		meta.With(k, v)
+	}
+
+	// extractors may log or register extractors themselves, so they are run without holding the lock
+	extractorMutex.RLock()
+	registered := extractors
+	extractorMutex.RUnlock()
+	for _, x := range registered {
+		for k, v := range x.e(ctx) {
+			meta.With(k, v)
+		}
+	}
+	return meta
+}
 
 // ContextWithLogger returns a new context with the provided logr.Logger added as a value in the context.
 func ContextWithLogger(ctx context.Context, l Logger) context.Context {
-	return context.WithValue(ctx, ContextKey, l)
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// ContextWithMeta returns a new context with the provided meta data merged into any meta data already in the context.
+// Keys in data replace existing keys with the same name.
+func ContextWithMeta(ctx context.Context, data Meta) context.Context {
+	meta := MetaFromContext(ctx).Copy()
+	for k, v := range data {
+		meta.With(k, v)
+	}
+	return context.WithValue(ctx, metaKey, meta)
+}
+
+// MetaFromContext returns the meta data accumulated in the given context by ContextWithMeta, or nil if there is none.
+func MetaFromContext(ctx context.Context) Meta {
+	if m, ok := ctx.Value(metaKey).(Meta); ok {
+		return m
+	}
+	return nil
 }
 
 // FromContext returns a logr.Logger from the given context if it has one,
 // it will return the default logr.Logger otherwise.
+// Any meta data added to the context with ContextWithMeta is attached to the returned logr.Logger.
 func FromContext(ctx context.Context) Logger {
-	if l, ok := ctx.Value(ContextKey).(Logger); ok {
-		return l
+	l, ok := ctx.Value(loggerKey).(Logger)
+	if !ok {
+		l = logr
+	}
+	if m := MetaFromContext(ctx); m != nil {
+		return l.With(m)
 	}
-	return logr
+	return l
 }
@@ -0,0 +1,77 @@
+package logr
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestContextWithMeta(t *testing.T) {
+	ctx := ContextWithMeta(context.Background(), Meta{"user": "u1", "tenant": "t1"})
+	child := ContextWithMeta(ctx, Meta{"user": "u2", "request": "r1"})
+
+	if got, want := MetaFromContext(ctx), (Meta{"user": "u1", "tenant": "t1"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("MetaFromContext() = %v, want %v", got, want)
+	}
+	if got, want := MetaFromContext(child), (Meta{"user": "u2", "tenant": "t1", "request": "r1"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("MetaFromContext() = %v, want %v", got, want)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if FromContext(context.Background()) != Default() {
+		t.Error("expected the default logger for an empty context")
+	}
+
+	l := With(Meta{"logger": "TestFromContext"})
+	ctx := ContextWithLogger(context.Background(), l)
+	if FromContext(ctx) != l {
+		t.Error("expected the logger stored in the context")
+	}
+
+	ctx = ContextWithMeta(ctx, Meta{"tenant": "t1"})
+	FromContext(ctx).Info("TestFromContext message")
+	Wait()
+
+	b := mustReadBuffer(buf, t)
+	if !bytes.Contains(b, []byte("logger:TestFromContext")) || !bytes.Contains(b, []byte("tenant:t1")) {
+		t.Errorf("expected buffer to contain logger and context meta. Got: %s", b)
+	}
+}
+
+func TestRegisterContextExtractor(t *testing.T) {
+	unregister := RegisterContextExtractor(func(ctx context.Context) Meta {
+		// extractors run without the lock held, so they may register extractors themselves
+		RegisterContextExtractor(func(ctx context.Context) Meta { return nil })()
+		return Meta{"extracted": "TestRegisterContextExtractor"}
+	})
+	Ctx(context.Background()).Info("TestRegisterContextExtractor registered")
+	unregister()
+	Ctx(context.Background()).Info("TestRegisterContextExtractor unregistered")
+	Wait()
+
+	b := mustReadBuffer(buf, t)
+	lines := bytes.Split(bytes.TrimSpace(b), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines. Got: %s", b)
+	}
+	if !bytes.Contains(lines[0], []byte("extracted:TestRegisterContextExtractor")) {
+		t.Errorf("expected extracted meta data. Got: %s", lines[0])
+	}
+	if bytes.Contains(lines[1], []byte("extracted")) {
+		t.Errorf("expected no extracted meta data after unregistering. Got: %s", lines[1])
+	}
+}
+
+func TestWithCtx(t *testing.T) {
+	l := &methodLogger{}
+	ctx := ContextWithMeta(context.Background(), Meta{"tenant": "t1"})
+	WithCtx(l, ctx)
+	if l.meta["tenant"] != "t1" {
+		t.Errorf("expected a Logger that is not a ContextLogger to get the meta data through With. Got: %v", l.meta)
+	}
+	if _, ok := WithCtx(Default(), ctx).(*Logr); !ok {
+		t.Error("expected a Logr from a Logr")
+	}
+}
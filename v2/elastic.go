@@ -0,0 +1,279 @@
+package logr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxElasticIndex is the maximum length of an index name in bytes
+const maxElasticIndex = 255
+
+type ElasticConfig struct {
+	http       []HTTPConfigModifier
+	index      string
+	dataStream bool
+	formatter  Formatter
+}
+
+type ElasticConfigModifier func(c ElasticConfig) ElasticConfig
+
+// WithIndexTemplate creates an ElasticConfigModifier that sets the index messages are written to. The template
+// takes the time in UTC and meta data like WithPartitionTemplate, e.g. logs-{service}-%Y.%m.%d. The default is
+// logs-%Y.%m.%d.
+func WithIndexTemplate(template string) ElasticConfigModifier {
+	return func(c ElasticConfig) ElasticConfig {
+		c.index = template
+		c.dataStream = false
+		return c
+	}
+}
+
+// WithDataStream creates an ElasticConfigModifier that writes messages to a data stream, which must match an index
+// template with data streams enabled. The name is a template like WithIndexTemplate, e.g. logs-{service}-default.
+func WithDataStream(name string) ElasticConfigModifier {
+	return func(c ElasticConfig) ElasticConfig {
+		c.index = name
+		c.dataStream = true
+		return c
+	}
+}
+
+// WithDocumentFormatter creates an ElasticConfigModifier that sets the Formatter of the documents, which must
+// produce a json object. The default is FormatECS.
+func WithDocumentFormatter(f Formatter) ElasticConfigModifier {
+	return func(c ElasticConfig) ElasticConfig {
+		c.formatter = f
+		return c
+	}
+}
+
+// WithElasticHTTP creates an ElasticConfigModifier that configures the batching, retries and requests like an
+// HTTPWriter, e.g. the authentication. The body format is always the bulk format.
+func WithElasticHTTP(configs ...HTTPConfigModifier) ElasticConfigModifier {
+	return func(c ElasticConfig) ElasticConfig {
+		c.http = append(append([]HTTPConfigModifier(nil), c.http...), configs...)
+		return c
+	}
+}
+
+// ElasticWriter is a MessageWriter that sends messages to the Elasticsearch or OpenSearch _bulk API in batches like
+// an HTTPWriter. Documents the cluster fails to index because it is overloaded are retried on their own, and other
+// failed documents are dropped and reported like a rejected batch. Documents are formatted by the document
+// formatter, so the Formatter given to AddWriter is not used. It implements Flusher and io.Closer.
+type ElasticWriter struct {
+	c        ElasticConfig
+	h        *HTTPWriter
+	segments []partitionSegment
+}
+
+// NewElasticWriter creates an ElasticWriter for the cluster at url, e.g. https://elastic:9200
+//
+//	w, err := logr.NewElasticWriter("https://elastic:9200", logr.WithIndexTemplate("logs-{service}-%Y.%m.%d"),
+//		logr.WithElasticHTTP(logr.WithBasicAuth("logr", password)))
+//	...
+//	logr.AddWriter(w)
+//	defer logr.Close()
+func NewElasticWriter(url string, configs ...ElasticConfigModifier) (*ElasticWriter, error) {
+	// default config
+	c := ElasticConfig{
+		index:     "logs-%Y.%m.%d",
+		formatter: FormatECS,
+	}
+	// apply optional extra config modifiers
+	for _, m := range configs {
+		c = m(c)
+	}
+
+	segments, err := parsePartitionTemplate(c.index)
+	if err != nil {
+		return nil, err
+	}
+	if err := elasticTemplate(c.index, segments); err != nil {
+		return nil, err
+	}
+	hc, err := httpConfig(c.http)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(url, "/_bulk") {
+		url = strings.TrimSuffix(url, "/") + "/_bulk"
+	}
+	action := "index"
+	if c.dataStream {
+		// data streams only accept new documents
+		action = "create"
+	}
+	enc := httpEncoder{
+		contentType: "application/x-ndjson",
+		encode: func(buf *bytes.Buffer, batch []httpEntry) error {
+			return encodeBulk(buf, batch, action)
+		},
+		accepted: func(body []byte, batch []httpEntry) ([]httpEntry, int, error) {
+			return bulkFailures(url, body, batch)
+		},
+	}
+	return &ElasticWriter{
+		c:        c,
+		h:        newHTTPWriter(url, hc, enc),
+		segments: segments,
+	}, nil
+}
+
+// Write implements io.Writer. p must be a json object and is written to the index for the current time.
+func (w *ElasticWriter) Write(p []byte) (int, error) {
+	return w.h.add(httpEntry{line: bytes.TrimRight(p, "\n"), key: w.indexName(time.Now(), nil)}, len(p))
+}
+
+// WriteMessage implements MessageWriter. It formats m as a document with the document formatter and writes it to its
+// index. p is not used, and reported as written.
+func (w *ElasticWriter) WriteMessage(m *Message, p []byte) (int, error) {
+	e := httpEntry{
+		line: bytes.TrimRight(w.c.formatter(m), "\n"),
+		key:  w.indexName(messageTime(m), m.Meta),
+	}
+	return w.h.add(e, len(p))
+}
+
+// Flush implements Flusher
+func (w *ElasticWriter) Flush() error {
+	return w.h.Flush()
+}
+
+// Close sends the current batch and waits for the batches in flight
+func (w *ElasticWriter) Close() error {
+	return w.h.Close()
+}
+
+// Health implements HealthReporter
+func (w *ElasticWriter) Health() WriterHealth {
+	return w.h.Health()
+}
+
+// indexName returns the index for a message logged at t with the meta data
+func (w *ElasticWriter) indexName(t time.Time, meta MetaData) string {
+	return elasticIndex(expandTemplate(w.segments, t.UTC(), meta, elasticName))
+}
+
+// elasticTemplate lowercases the text of an index template and checks it is allowed in an index name, as only the
+// meta data values are made safe when a message is written
+func elasticTemplate(template string, segments []partitionSegment) error {
+	for i := range segments {
+		s := &segments[i]
+		if s.text == "" {
+			continue
+		}
+		s.text = strings.ToLower(s.text)
+		if j := strings.IndexAny(s.text, elasticInvalid); j >= 0 {
+			return fmt.Errorf("logr: index template %q contains %q, which is not allowed in an index name", template,
+				s.text[j:j+1])
+		}
+		if i == 0 && strings.IndexAny(s.text[:1], "-_+") == 0 {
+			return fmt.Errorf("logr: index template %q may not start with %q", template, s.text[:1])
+		}
+	}
+	return nil
+}
+
+// elasticInvalid holds the characters that are not allowed in an index name
+const elasticInvalid = `\/*?"<>| ,#:`
+
+// elasticName makes a meta data value safe to use as part of an index name, which is lowercase and excludes some
+// punctuation
+func elasticName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(elasticInvalid, r) {
+			return '_'
+		}
+		return r
+	}, strings.ToLower(s))
+	if s == "" {
+		return "_"
+	}
+	return s
+}
+
+// elasticIndex makes an index name valid: it may not start with -, _ or +, be . or .., or be longer than 255 bytes.
+// Names left empty are written as unknown, like missing meta data keys.
+func elasticIndex(s string) string {
+	s = strings.TrimLeft(s, "-_+")
+	if s == "" || s == "." || s == ".." {
+		return "unknown"
+	}
+	if len(s) > maxElasticIndex {
+		cut := maxElasticIndex
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = s[:cut]
+	}
+	return s
+}
+
+// encodeBulk writes the entries as bulk actions on their index
+func encodeBulk(buf *bytes.Buffer, batch []httpEntry, action string) error {
+	var scratch []byte
+	for _, e := range batch {
+		scratch = append(scratch[:0], `{"`...)
+		scratch = append(scratch, action...)
+		scratch = append(scratch, `":{"_index":`...)
+		scratch = appendJSONString(scratch, e.key)
+		scratch = append(scratch, "}}\n"...)
+		buf.Write(scratch)
+		buf.Write(e.line)
+		buf.WriteByte('\n')
+	}
+	return nil
+}
+
+// bulkResponse is the part of a bulk response describing the result of each action
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// bulkFailures reads a bulk response and returns the entries that failed because the cluster was overloaded, to be
+// retried, the number of entries that failed for other reasons and an error describing every failed entry
+func bulkFailures(url string, body []byte, batch []httpEntry) ([]httpEntry, int, error) {
+	var resp bulkResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, len(batch), fmt.Errorf("logr: invalid bulk response from %s: %w", url, err)
+	}
+	if !resp.Errors {
+		return nil, 0, nil
+	}
+	if len(resp.Items) != len(batch) {
+		return nil, len(batch), fmt.Errorf("logr: bulk response from %s has %d items for %d documents", url,
+			len(resp.Items), len(batch))
+	}
+	var retry []httpEntry
+	failed, reason := 0, ""
+	for i, item := range resp.Items {
+		for _, r := range item {
+			if r.Status < 300 {
+				continue
+			}
+			if failed++; reason == "" {
+				reason = fmt.Sprintf("%d %s: %s", r.Status, r.Error.Type, r.Error.Reason)
+			}
+			if r.Status == 429 || r.Status >= 500 {
+				retry = append(retry, batch[i])
+			}
+		}
+	}
+	if failed == 0 {
+		return nil, 0, nil
+	}
+	err := fmt.Errorf("logr: %s failed to index %d of %d documents, %d to retry: %s", url, failed, len(batch),
+		len(retry), reason)
+	return retry, failed - len(retry), err
+}
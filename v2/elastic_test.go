@@ -0,0 +1,217 @@
+package logr
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// bulkServer is a test _bulk endpoint that fails the first attempt at the documents whose message is a status code
+type bulkServer struct {
+	mu       sync.Mutex
+	requests [][]string
+	seen     map[string]bool
+}
+
+func (s *bulkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var lines []string
+	sc := bufio.NewScanner(r.Body)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, lines)
+	var items []string
+	for i := 1; i < len(lines); i += 2 {
+		var doc map[string]any
+		json.Unmarshal([]byte(lines[i]), &doc)
+		status := 201
+		fmt.Sscan(fmt.Sprint(doc["message"]), &status)
+		if status == 201 || s.seen[lines[i]] {
+			items = append(items, `{"index":{"status":201}}`)
+			continue
+		}
+		s.seen[lines[i]] = true
+		items = append(items, fmt.Sprintf(`{"index":{"status":%d,"error":{"type":"failure","reason":"status %d"}}}`,
+			status, status))
+	}
+	fmt.Fprintf(w, `{"took":1,"errors":%v,"items":[%s]}`, strings.Contains(strings.Join(items, ""), "error"),
+		strings.Join(items, ","))
+}
+
+func TestElasticWriterRetriesFailedDocuments(t *testing.T) {
+	bs := &bulkServer{seen: map[string]bool{}}
+	s := httptest.NewServer(bs)
+	defer s.Close()
+	var dropped error
+	w, err := NewElasticWriter(s.URL, WithIndexTemplate("logs-{service}-%Y.%m.%d"), WithElasticHTTP(
+		WithFlushInterval(0), WithRetryBackoff(time.Millisecond, time.Millisecond),
+		WithErrorHandler(func(err error) { dropped = err }),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	at := time.Date(2026, 10, 18, 23, 30, 0, 0, time.FixedZone("EST", -5*3600))
+	for _, m := range []*Message{
+		{Type: I, Desc: "ok", Meta: MetaData{"service": "API/v1"}},
+		{Type: E, Desc: "429", Meta: MetaData{"service": "api"}},
+		{Type: W, Desc: "400"},
+	} {
+		m.Timestamp = at
+		w.WriteMessage(m, nil)
+	}
+	w.Flush()
+
+	if len(bs.requests) != 2 {
+		t.Fatalf("expected the overloaded document to be retried once. Got: %q", bs.requests)
+	}
+	first := bs.requests[0]
+	want := []string{
+		`{"index":{"_index":"logs-api_v1-2026.10.19"}}`,
+		`{"index":{"_index":"logs-api-2026.10.19"}}`,
+		`{"index":{"_index":"logs-unknown-2026.10.19"}}`,
+	}
+	for i, action := range want {
+		if first[i*2] != action {
+			t.Errorf("expected %s. Got: %s", action, first[i*2])
+		}
+	}
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(first[1]), &doc); err != nil || doc["@timestamp"] != "2026-10-19T04:30:00Z" ||
+		doc["log.level"] != "info" {
+		t.Errorf("expected an ECS document. Got: %s", first[1])
+	}
+	if retried := bs.requests[1]; len(retried) != 2 || !strings.Contains(retried[1], `"message":"429"`) {
+		t.Errorf("expected only the overloaded document to be retried. Got: %q", retried)
+	}
+	if dropped == nil || !strings.Contains(dropped.Error(), "failed to index 2 of 3 documents, 1 to retry: 429") {
+		t.Errorf("expected the rejected document to be reported. Got: %v", dropped)
+	}
+	if h := w.Health(); h.Dropped != 1 {
+		t.Errorf("expected one document to be dropped. Got: %+v", h)
+	}
+}
+
+func TestElasticWriterDataStream(t *testing.T) {
+	rec := &httpRecorder{}
+	s := httptest.NewServer(rec)
+	defer s.Close()
+	w, err := NewElasticWriter(s.URL+"/_bulk", WithDataStream("logs-{elastic_test}-default"),
+		WithElasticHTTP(WithFlushInterval(0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	stop := AddWriter(w)
+	defer stop()
+
+	With(Meta{"elastic_test": "Tests"}).Info("TestElasticWriterDataStream")
+	Wait()
+	w.Flush()
+	if len(rec.bodies) != 1 {
+		t.Fatalf("expected a single request. Got: %q", rec.bodies)
+	}
+	lines := strings.Split(rec.bodies[0], "\n")
+	if lines[0] != `{"create":{"_index":"logs-tests-default"}}` || !strings.Contains(lines[1], "TestElasticWriterDataStream") {
+		t.Errorf("expected a create action on the data stream. Got: %q", lines)
+	}
+	if rec.headers[0].Get("Content-Type") != "application/x-ndjson" {
+		t.Errorf("expected an ndjson request. Got: %v", rec.headers[0])
+	}
+}
+
+func TestBulkFailures(t *testing.T) {
+	batch := []httpEntry{{key: "a"}, {key: "b"}}
+	tests := []struct {
+		name    string
+		body    string
+		retry   int
+		dropped int
+		err     string
+	}{
+		{name: "success", body: `{"errors":false,"items":[]}`},
+		{name: "invalid", body: `<html>`, dropped: 2, err: "invalid bulk response"},
+		{
+			name:    "mismatch",
+			body:    `{"errors":true,"items":[{"index":{"status":500}}]}`,
+			dropped: 2,
+			err:     "1 items for 2 documents",
+		},
+		{
+			name:    "partial",
+			body:    `{"errors":true,"items":[{"create":{"status":503}},{"create":{"status":409}}]}`,
+			retry:   1,
+			dropped: 1,
+			err:     "failed to index 2 of 2 documents, 1 to retry: 503",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retry, dropped, err := bulkFailures("url", []byte(tt.body), batch)
+			if len(retry) != tt.retry || dropped != tt.dropped {
+				t.Errorf("expected %d to retry and %d dropped. Got: %v, %d", tt.retry, tt.dropped, retry, dropped)
+			}
+			if (err == nil) != (tt.err == "") || err != nil && !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error %q. Got: %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestElasticIndex(t *testing.T) {
+	long := strings.Repeat("a", 254) + "é"
+	tests := map[string]string{
+		"logs-api-2026.10.19": "logs-api-2026.10.19",
+		"-api-2026.10.19":     "api-2026.10.19",
+		"_+-api":              "api",
+		"_":                   "unknown",
+		".":                   "unknown",
+		"..":                  "unknown",
+		"...":                 "...",
+		long:                  strings.Repeat("a", 254),
+	}
+	for in, want := range tests {
+		if got := elasticIndex(in); got != want {
+			t.Errorf("expected %q to be %q. Got: %q", in, want, got)
+		}
+	}
+}
+
+func TestElasticIndexTemplate(t *testing.T) {
+	ts := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := map[string]string{
+		"Logs-{service}-%Y.%m.%d": "logs-api-2026.10.19",
+		"LOGS-default":            "logs-default",
+		"logs {service}":          "",
+		"logs-*":                  "",
+		"logs-{service}:%Y":       "",
+		"_logs-{service}":         "",
+	}
+	for template, want := range tests {
+		w, err := NewElasticWriter("http://localhost:9200", WithIndexTemplate(template))
+		if want == "" {
+			if err == nil {
+				t.Errorf("expected %q to be rejected", template)
+				w.Close()
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expected %q to be accepted. Got: %v", template, err)
+			continue
+		}
+		if got := w.indexName(ts, MetaData{"service": "API"}); got != want {
+			t.Errorf("expected %q to expand to %q. Got: %q", template, want, got)
+		}
+		w.Close()
+	}
+}
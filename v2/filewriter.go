@@ -0,0 +1,535 @@
+package logr
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeLayout is the layout of the time in the name of rotated files
+const backupTimeLayout = "2006-01-02T15-04-05.000"
+
+// RotationInterval defines how often a FileWriter starts a new file regardless of its size
+type RotationInterval int
+
+// Available rotation intervals
+const (
+	RotateNever  RotationInterval = iota // only rotate by size
+	RotateHourly                         // rotate at the start of every hour
+	RotateDaily                          // rotate at midnight
+)
+
+type FileConfig struct {
+	maxSize    int64
+	interval   RotationInterval
+	maxBackups int
+	maxAge     time.Duration
+	compress   bool
+	utc        bool
+	dirMode    os.FileMode
+	fileMode   os.FileMode
+	bufferSize int
+	signals    []os.Signal
+	reopen     <-chan struct{}
+}
+
+type FileConfigModifier func(c FileConfig) FileConfig
+
+// WithMaxSize creates a FileConfigModifier that rotates the file before a write would make it larger than n bytes.
+// Zero, the default, disables rotation by size.
+func WithMaxSize(n int64) FileConfigModifier {
+	return func(c FileConfig) FileConfig {
+		c.maxSize = n
+		return c
+	}
+}
+
+// WithRotateEvery creates a FileConfigModifier that rotates the file every hour or day, in addition to any rotation by
+// size
+func WithRotateEvery(i RotationInterval) FileConfigModifier {
+	return func(c FileConfig) FileConfig {
+		c.interval = i
+		return c
+	}
+}
+
+// WithMaxBackups creates a FileConfigModifier that keeps at most n rotated files, removing the oldest. Zero, the
+// default, keeps them all.
+func WithMaxBackups(n int) FileConfigModifier {
+	return func(c FileConfig) FileConfig {
+		c.maxBackups = n
+		return c
+	}
+}
+
+// WithMaxAge creates a FileConfigModifier that removes rotated files older than d. Zero, the default, keeps them
+// regardless of age.
+func WithMaxAge(d time.Duration) FileConfigModifier {
+	return func(c FileConfig) FileConfig {
+		c.maxAge = d
+		return c
+	}
+}
+
+// WithCompression creates a FileConfigModifier that compresses rotated files with gzip in the background
+func WithCompression() FileConfigModifier {
+	return func(c FileConfig) FileConfig {
+		c.compress = true
+		return c
+	}
+}
+
+// WithUTC creates a FileConfigModifier that uses UTC for the names of rotated files and the hourly and daily rotation
+// times, instead of local time
+func WithUTC() FileConfigModifier {
+	return func(c FileConfig) FileConfig {
+		c.utc = true
+		return c
+	}
+}
+
+// WithDirMode creates a FileConfigModifier that sets the permissions of the directories created for the file. The
+// default is 0755.
+func WithDirMode(mode os.FileMode) FileConfigModifier {
+	return func(c FileConfig) FileConfig {
+		c.dirMode = mode
+		return c
+	}
+}
+
+// WithFileMode creates a FileConfigModifier that sets the permissions of new files. The default is 0644.
+func WithFileMode(mode os.FileMode) FileConfigModifier {
+	return func(c FileConfig) FileConfig {
+		c.fileMode = mode
+		return c
+	}
+}
+
+// WithFileBuffer creates a FileConfigModifier that buffers up to n bytes in memory before writing them to the file.
+// Buffered output is written when the buffer is full, when the file rotates and by Flush and Close. The default is to
+// write every message directly.
+func WithFileBuffer(n int) FileConfigModifier {
+	return func(c FileConfig) FileConfig {
+		c.bufferSize = n
+		return c
+	}
+}
+
+// WithReopenOnSignal creates a FileConfigModifier that reopens the file when the process receives one of the given
+// signals, or SIGHUP if none are given on platforms that have it. This supports the system logrotate without
+// copytruncate, which renames the file and then signals the process to start a new one.
+func WithReopenOnSignal(sigs ...os.Signal) FileConfigModifier {
+	return func(c FileConfig) FileConfig {
+		if len(sigs) == 0 {
+			sigs = defaultReopenSignals()
+		}
+		c.signals = sigs
+		return c
+	}
+}
+
+// WithReopenOn creates a FileConfigModifier that reopens the file every time a value is received from ch
+func WithReopenOn(ch <-chan struct{}) FileConfigModifier {
+	return func(c FileConfig) FileConfig {
+		c.reopen = ch
+		return c
+	}
+}
+
+// FileWriter is an io.Writer that writes to a file and rotates it by size, by time or both. Rotated files are renamed
+// with the time they were rotated, e.g. app-2026-10-18T15-04-05.000.log, and are optionally compressed and removed
+// when there are too many or they are too old. It implements Flusher and io.Closer for use with Flush and Close.
+type FileWriter struct {
+	c    FileConfig
+	path string
+	loc  *time.Location
+	now  func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	buf    *bufio.Writer
+	size   int64
+	next   time.Time
+	closed bool
+
+	millMu sync.Mutex
+	wg     sync.WaitGroup
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewFileWriter creates a FileWriter that appends to the file at path, creating it and its directories if needed.
+//
+//	w, err := logr.NewFileWriter("/var/log/app/app.log", logr.WithMaxSize(100<<20), logr.WithMaxBackups(10))
+//	...
+//	logr.AddWriter(w)
+//	defer logr.Close()
+func NewFileWriter(path string, configs ...FileConfigModifier) (*FileWriter, error) {
+	// default config
+	c := FileConfig{
+		dirMode:  0755,
+		fileMode: 0644,
+	}
+	// apply optional extra config modifiers
+	for _, m := range configs {
+		c = m(c)
+	}
+
+	w := &FileWriter{
+		c:    c,
+		path: path,
+		loc:  time.Local,
+		now:  time.Now,
+		stop: make(chan struct{}),
+	}
+	if c.utc {
+		w.loc = time.UTC
+	}
+	w.mu.Lock()
+	err := w.open()
+	w.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if len(c.signals) > 0 || c.reopen != nil {
+		w.wg.Add(1)
+		go w.watchReopen()
+	}
+	return w, nil
+}
+
+// Write writes p to the file, rotating it first if p would make it too large or its rotation time has passed
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	now := w.now()
+	if !w.next.IsZero() && !now.Before(w.next) || w.c.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.c.maxSize {
+		if err := w.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+	var n int
+	var err error
+	if w.buf != nil {
+		n, err = w.buf.Write(p)
+	} else {
+		n, err = w.file.Write(p)
+	}
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it and starts a new one
+func (w *FileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	return w.rotate(w.now())
+}
+
+// Reopen writes any buffered output, closes the file and opens the path again, creating a new file if the old one was
+// moved. Reopening triggered by WithReopenOnSignal or WithReopenOn runs in the logr listener goroutine between writes,
+// so every message is written whole to either the old or the new file.
+func (w *FileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	if err := w.closeFile(); err != nil {
+		return fmt.Errorf("logr: failed to close log file: %w", err)
+	}
+	return w.open()
+}
+
+// watchReopen reopens the file for every signal or value received until the FileWriter is closed
+func (w *FileWriter) watchReopen() {
+	defer w.wg.Done()
+	var sigs chan os.Signal
+	if len(w.c.signals) > 0 {
+		sigs = make(chan os.Signal, 1)
+		signal.Notify(sigs, w.c.signals...)
+		defer signal.Stop(sigs)
+	}
+	reopen := w.c.reopen
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-sigs:
+		case _, ok := <-reopen:
+			if !ok {
+				reopen = nil
+				continue
+			}
+		}
+		ran, err := runInListener(w.Reopen, w.stop)
+		if !ran {
+			return
+		}
+		if err != nil && !errors.Is(err, os.ErrClosed) {
+			fmt.Printf("failed to reopen log file in logr.FileWriter: %v\n", err)
+		}
+	}
+}
+
+// Flush implements Flusher. It writes any buffered output and commits the file to stable storage.
+func (w *FileWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	if w.buf != nil {
+		if err := w.buf.Flush(); err != nil {
+			return err
+		}
+	}
+	return w.file.Sync()
+}
+
+// Close writes any buffered output, closes the file and waits for rotated files to be compressed and removed. Writes
+// after Close return os.ErrClosed.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	err := w.closeFile()
+	w.closed = true
+	w.mu.Unlock()
+	w.stopOnce.Do(func() { close(w.stop) })
+	w.wg.Wait()
+	return err
+}
+
+// open opens the file for appending, creating it and its directories if needed
+func (w *FileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), w.c.dirMode); err != nil {
+		return fmt.Errorf("logr: failed to create log directory: %w", err)
+	}
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, w.c.fileMode)
+	if err != nil {
+		return fmt.Errorf("logr: failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("logr: failed to open log file: %w", err)
+	}
+	w.file = f
+	w.size = info.Size()
+	if w.c.bufferSize > 0 {
+		w.buf = bufio.NewWriterSize(f, w.c.bufferSize)
+	}
+	// an existing file written in an earlier period is rotated by the first write
+	started := w.now()
+	if w.size > 0 {
+		started = info.ModTime()
+	}
+	w.next = w.nextRotation(started)
+	return nil
+}
+
+// closeFile writes any buffered output and closes the file
+func (w *FileWriter) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	var err error
+	if w.buf != nil {
+		err = w.buf.Flush()
+	}
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	w.file = nil
+	w.buf = nil
+	return err
+}
+
+// rotate renames the current file to a backup, opens a new file and starts removing and compressing old backups
+func (w *FileWriter) rotate(now time.Time) error {
+	if err := w.closeFile(); err != nil {
+		return fmt.Errorf("logr: failed to close log file: %w", err)
+	}
+	if _, err := os.Stat(w.path); err == nil {
+		if err := os.Rename(w.path, w.backupName(now)); err != nil {
+			return fmt.Errorf("logr: failed to rotate log file: %w", err)
+		}
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	if w.c.compress || w.c.maxBackups > 0 || w.c.maxAge > 0 {
+		w.wg.Add(1)
+		go w.mill(now)
+	}
+	return nil
+}
+
+// nextRotation returns the time after t at which the file is rotated, or zero if it is only rotated by size
+func (w *FileWriter) nextRotation(t time.Time) time.Time {
+	t = t.In(w.loc)
+	switch w.c.interval {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, w.loc)
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, w.loc)
+	}
+	return time.Time{}
+}
+
+// prefixAndExt splits the base name of the file into the parts placed before and after the time in backup names
+func (w *FileWriter) prefixAndExt() (prefix, ext string) {
+	base := filepath.Base(w.path)
+	ext = filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "-", ext
+}
+
+// backupName returns an unused name for a file rotated at t
+func (w *FileWriter) backupName(t time.Time) string {
+	prefix, ext := w.prefixAndExt()
+	name := prefix + t.In(w.loc).Format(backupTimeLayout)
+	dir := filepath.Dir(w.path)
+	for i := 0; ; i++ {
+		candidate := name
+		if i > 0 {
+			candidate += "-" + strconv.Itoa(i)
+		}
+		candidate = filepath.Join(dir, candidate+ext)
+		_, err := os.Stat(candidate)
+		_, gzErr := os.Stat(candidate + ".gz")
+		if errors.Is(err, os.ErrNotExist) && errors.Is(gzErr, os.ErrNotExist) {
+			return candidate
+		}
+	}
+}
+
+// backup is a rotated file
+type backup struct {
+	path string
+	time time.Time
+	seq  int
+}
+
+// backups returns the rotated files, newest first
+func (w *FileWriter) backups() ([]backup, error) {
+	dir := filepath.Dir(w.path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix, ext := w.prefixAndExt()
+	var list []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		s := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz")
+		if !strings.HasSuffix(s, ext) || len(s) < len(backupTimeLayout)+len(ext) {
+			continue
+		}
+		s = strings.TrimSuffix(s, ext)
+		t, err := time.ParseInLocation(backupTimeLayout, s[:len(backupTimeLayout)], w.loc)
+		if err != nil {
+			continue
+		}
+		seq := 0
+		if rest := s[len(backupTimeLayout):]; rest != "" {
+			if seq, err = strconv.Atoi(strings.TrimPrefix(rest, "-")); err != nil || rest[0] != '-' {
+				continue
+			}
+		}
+		list = append(list, backup{path: filepath.Join(dir, name), time: t, seq: seq})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].time.Equal(list[j].time) {
+			return list[i].time.After(list[j].time)
+		}
+		return list[i].seq > list[j].seq
+	})
+	return list, nil
+}
+
+// mill removes the backups beyond the maximum count or age and compresses the rest. It runs in the background after
+// every rotation, one at a time. Errors are printed rather than logged, as Close waits for mill while the listener may
+// be waiting for Close.
+func (w *FileWriter) mill(now time.Time) {
+	defer w.wg.Done()
+	w.millMu.Lock()
+	defer w.millMu.Unlock()
+
+	list, err := w.backups()
+	if err != nil {
+		fmt.Printf("failed to list rotated log files in logr.FileWriter: %v\n", err)
+		return
+	}
+	for i, b := range list {
+		if w.c.maxBackups > 0 && i >= w.c.maxBackups || w.c.maxAge > 0 && now.Sub(b.time) > w.c.maxAge {
+			if err := os.Remove(b.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				fmt.Printf("failed to remove rotated log file in logr.FileWriter: %v\n", err)
+			}
+			continue
+		}
+		if w.c.compress && !strings.HasSuffix(b.path, ".gz") {
+			if err := compressFile(b.path, w.c.fileMode); err != nil {
+				fmt.Printf("failed to compress rotated log file in logr.FileWriter: %v\n", err)
+			}
+		}
+	}
+}
+
+// compressFile gzips the file at path to path.gz and removes the original
+func compressFile(path string, mode os.FileMode) (err error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := path + ".gz.tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(tmp)
+		}
+	}()
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path+".gz"); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(path)
+}
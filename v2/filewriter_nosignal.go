@@ -0,0 +1,11 @@
+//go:build js || plan9
+
+package logr
+
+import "os"
+
+// defaultReopenSignals returns the signals WithReopenOnSignal uses when none are given. There is no SIGHUP on this
+// platform, so the signals must be given explicitly.
+func defaultReopenSignals() []os.Signal {
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !js && !plan9
+
+package logr
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultReopenSignals returns the signals WithReopenOnSignal uses when none are given
+func defaultReopenSignals() []os.Signal {
+	return []os.Signal{syscall.SIGHUP}
+}
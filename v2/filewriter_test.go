@@ -0,0 +1,234 @@
+package logr
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock returns a clock for a FileWriter that only moves when advanced
+func fakeClock(t time.Time) (now func() time.Time, advance func(time.Duration)) {
+	return func() time.Time { return t }, func(d time.Duration) { t = t.Add(d) }
+}
+
+// dirFiles returns the sorted names of the files in dir
+func dirFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestFileWriterRotatesBySize(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs", "nested")
+	path := filepath.Join(dir, "app.log")
+	w, err := NewFileWriter(path, WithMaxSize(10), WithUTC(), WithDirMode(0700))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now, advance := fakeClock(time.Date(2026, 10, 18, 15, 4, 5, 0, time.UTC))
+	w.now = now
+
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddd\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		advance(time.Millisecond)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"app-2026-10-18T15-04-05.002.log", "app.log"}
+	if got := dirFiles(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected files %v. Got: %v", want, got)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, want[0])); string(b) != "aaaa\nbbbb\n" {
+		t.Errorf("expected the first segment in the backup. Got: %q", b)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "cccc\ndddd\n" {
+		t.Errorf("expected the last segment in the file. Got: %q", b)
+	}
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("expected the directory to be created with mode 0700. Got: %v, %v", info.Mode().Perm(), err)
+	}
+	if _, err := w.Write([]byte("late\n")); err != os.ErrClosed {
+		t.Errorf("expected writes after Close to fail. Got: %v", err)
+	}
+}
+
+func TestFileWriterRotatesByTime(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	now, advance := fakeClock(time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC))
+	w, err := NewFileWriter(path, WithRotateEvery(RotateDaily), WithUTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = now
+	w.next = w.nextRotation(now())
+
+	w.Write([]byte("day one\n"))
+	advance(2 * time.Minute)
+	w.Write([]byte("day two\n"))
+	w.Close()
+
+	want := []string{"app-2026-10-19T00-01-00.000.log", "app.log"}
+	if got := dirFiles(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected files %v. Got: %v", want, got)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "day two\n" {
+		t.Errorf("expected the new day in the file. Got: %q", b)
+	}
+
+	if got := w.nextRotation(time.Date(2026, 10, 18, 15, 4, 5, 0, time.UTC)); !got.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected daily rotation time %v", got)
+	}
+	w.c.interval = RotateHourly
+	if got := w.nextRotation(time.Date(2026, 10, 18, 15, 4, 5, 0, time.UTC)); !got.Equal(time.Date(2026, 10, 18, 16, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected hourly rotation time %v", got)
+	}
+}
+
+func TestFileWriterBackupsAndCompression(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w, err := NewFileWriter(path, WithMaxBackups(2), WithMaxAge(time.Hour), WithCompression(), WithUTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now, advance := fakeClock(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+	w.now = now
+
+	// an old backup is removed by age
+	old := filepath.Join(dir, "app-2026-10-18T09-00-00.000.log")
+	if err := os.WriteFile(old, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"one\n", "two\n", "three\n", "four\n"} {
+		w.Write([]byte(s))
+		if err := w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		advance(time.Second)
+	}
+	w.Close()
+
+	want := []string{"app-2026-10-18T12-00-02.000.log.gz", "app-2026-10-18T12-00-03.000.log.gz", "app.log"}
+	if got := dirFiles(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected files %v. Got: %v", want, got)
+	}
+	f, err := os.Open(filepath.Join(dir, want[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(gz); string(b) != "four\n" {
+		t.Errorf("expected the compressed backup to hold the last segment. Got: %q", b)
+	}
+}
+
+func TestFileWriterFlushAndClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := NewFileWriter(path, WithFileBuffer(4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := AddWriter(w, WithFormatter(FormatJSON))
+	defer stop()
+
+	Info("TestFileWriterFlushAndClose")
+	Wait()
+	if b, _ := os.ReadFile(path); len(b) != 0 {
+		t.Errorf("expected the message to be buffered. Got: %q", b)
+	}
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); !strings.Contains(string(b), "TestFileWriterFlushAndClose") {
+		t.Errorf("expected Flush to write the message. Got: %q", b)
+	}
+
+	Info("TestFileWriterFlushAndClose last")
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); !strings.Contains(string(b), "TestFileWriterFlushAndClose last") {
+		t.Errorf("expected Close to write the last message. Got: %q", b)
+	}
+	if _, err := w.Write([]byte("late\n")); err != os.ErrClosed {
+		t.Errorf("expected the writer to be closed. Got: %v", err)
+	}
+}
+
+func TestFileWriterReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	reopen := make(chan struct{})
+	w, err := NewFileWriter(path, WithReopenOn(reopen))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	stop := AddWriter(w, WithFormatter(FormatJSON))
+	defer stop()
+
+	Info("TestFileWriterReopen before")
+	Wait()
+	// logrotate moves the file and then signals the process
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	reopen <- struct{}{}
+	for i := 0; i < 1000; i++ {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	Info("TestFileWriterReopen after")
+	Wait()
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	old, _ := os.ReadFile(path + ".1")
+	cur, _ := os.ReadFile(path)
+	if !strings.Contains(string(old), "before") || strings.Contains(string(old), "after") {
+		t.Errorf("expected only the first message in the moved file. Got: %q", old)
+	}
+	if !strings.Contains(string(cur), "after") || strings.Contains(string(cur), "before") {
+		t.Errorf("expected only the second message in the new file. Got: %q", cur)
+	}
+}
+
+func TestFileWriterReopenOnSignal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := NewFileWriter(path, WithReopenOnSignal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.c.signals) != 1 || !reflect.DeepEqual(w.c.signals, defaultReopenSignals()) {
+		t.Errorf("expected SIGHUP by default. Got: %v", w.c.signals)
+	}
+	// Close stops watching for signals
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
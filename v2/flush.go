@@ -0,0 +1,79 @@
+package logr
+
+import (
+	"io"
+	"os"
+)
+
+// Flusher is implemented by writers that buffer output, such as FileWriter. Flush and Close call it for every writer
+// that implements it.
+type Flusher interface {
+	Flush() error
+}
+
+// controlMessage asks the listener to flush, and optionally close, the writers, or to run fn between writes
+type controlMessage struct {
+	close bool
+	fn    func() error
+	done  chan error
+}
+
+var control = make(chan controlMessage)
+
+// Flush waits for the queued messages to be written and then flushes every writer that implements Flusher. It returns
+// the first error returned by a writer.
+func Flush() error {
+	return controlWriters(false)
+}
+
+// Close waits for the queued messages to be written, flushes every writer that implements Flusher and then closes and
+// removes every writer that implements io.Closer, except os.Stdout and os.Stderr. It returns the first error returned
+// by a writer. Call it before the program exits so buffered output is not lost.
+//
+//	defer logr.Close()
+func Close() error {
+	return controlWriters(true)
+}
+
+// controlWriters waits for the queued messages to be written and has the listener flush or close the writers, so it
+// does not happen during a write
+func controlWriters(close bool) error {
+	Wait()
+	cm := controlMessage{close: close, done: make(chan error, 1)}
+	control <- cm
+	return <-cm.done
+}
+
+// runInListener runs fn in the listener goroutine between writes and returns its error. It reports false without
+// running fn if stop is closed first.
+func runInListener(fn func() error, stop <-chan struct{}) (bool, error) {
+	cm := controlMessage{fn: fn, done: make(chan error, 1)}
+	select {
+	case control <- cm:
+		return true, <-cm.done
+	case <-stop:
+		return false, nil
+	}
+}
+
+// flushWriters flushes, and optionally closes and removes, the writers. It is called by the listener.
+func flushWriters(close bool) error {
+	var first error
+	for c, w := range writers {
+		if f, ok := w.(Flusher); ok {
+			if err := f.Flush(); err != nil && first == nil {
+				first = err
+			}
+		}
+		if !close || w == io.Writer(os.Stdout) || w == io.Writer(os.Stderr) {
+			continue
+		}
+		if cl, ok := w.(io.Closer); ok {
+			if err := cl.Close(); err != nil && first == nil {
+				first = err
+			}
+			delete(writers, c)
+		}
+	}
+	return first
+}
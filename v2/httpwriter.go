@@ -0,0 +1,702 @@
+package logr
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxErrorBody is how much of a failed response body is kept in an HTTPError
+const maxErrorBody = 1024
+
+// maxResponseBody is how much of a response body is read, so a misbehaving endpoint cannot use unbounded memory
+const maxResponseBody = 8 << 20
+
+// BodyFormat defines how an HTTPWriter encodes a batch of messages
+type BodyFormat int
+
+// Available body formats
+const (
+	BodyNDJSON    BodyFormat = iota // one message per line, for messages formatted as JSON
+	BodyJSONArray                   // a JSON array of the messages, for messages formatted as JSON
+)
+
+type HTTPConfig struct {
+	format       BodyFormat
+	gzip         bool
+	header       http.Header
+	batchSize    int
+	batchBytes   int
+	interval     time.Duration
+	retries      int
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	concurrency  int
+	queueLimit   int
+	flushTimeout time.Duration
+	client       *http.Client
+	onError      func(error)
+}
+
+type HTTPConfigModifier func(c HTTPConfig) HTTPConfig
+
+// WithBodyFormat creates an HTTPConfigModifier that sets how batches are encoded. The default is BodyNDJSON.
+func WithBodyFormat(f BodyFormat) HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		c.format = f
+		return c
+	}
+}
+
+// WithGzip creates an HTTPConfigModifier that compresses request bodies with gzip
+func WithGzip() HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		c.gzip = true
+		return c
+	}
+}
+
+// WithHeader creates an HTTPConfigModifier that adds a header to every request
+func WithHeader(key, value string) HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		c.header = c.header.Clone()
+		c.header.Add(key, value)
+		return c
+	}
+}
+
+// WithBasicAuth creates an HTTPConfigModifier that authenticates every request with a username and password
+func WithBasicAuth(username, password string) HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		r := http.Request{Header: http.Header{}}
+		r.SetBasicAuth(username, password)
+		c.header = c.header.Clone()
+		c.header.Set("Authorization", r.Header.Get("Authorization"))
+		return c
+	}
+}
+
+// WithBearerToken creates an HTTPConfigModifier that authenticates every request with a bearer token
+func WithBearerToken(token string) HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		c.header = c.header.Clone()
+		c.header.Set("Authorization", "Bearer "+token)
+		return c
+	}
+}
+
+// WithBatchSize creates an HTTPConfigModifier that sends a batch once it holds n messages. The default is 500.
+func WithBatchSize(n int) HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		c.batchSize = n
+		return c
+	}
+}
+
+// WithBatchBytes creates an HTTPConfigModifier that sends a batch before it grows larger than n bytes. The default is
+// 1MB.
+func WithBatchBytes(n int) HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		c.batchBytes = n
+		return c
+	}
+}
+
+// WithFlushInterval creates an HTTPConfigModifier that sets the longest time a message waits in a batch before it is
+// sent. An interval of 0 sends batches only when they are full or flushed, and intervals under 4ns are rejected. The
+// default is 1 second.
+func WithFlushInterval(d time.Duration) HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		c.interval = d
+		return c
+	}
+}
+
+// WithRetries creates an HTTPConfigModifier that sets how many times a batch is retried after a network error, a 429
+// or a 5xx response before it is dropped. The default is 5.
+func WithRetries(n int) HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		c.retries = n
+		return c
+	}
+}
+
+// WithRetryBackoff creates an HTTPConfigModifier that sets the delay before retrying a batch, which doubles after
+// every attempt from min up to max. A Retry-After header in the response takes precedence, up to max. The default is
+// 500ms to 30s.
+func WithRetryBackoff(min, max time.Duration) HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		c.minBackoff = min
+		c.maxBackoff = max
+		return c
+	}
+}
+
+// WithConcurrency creates an HTTPConfigModifier that sets how many batches may be in flight at once. Full batches
+// wait in a queue while all of them are. The default is 2.
+func WithConcurrency(n int) HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		c.concurrency = n
+		return c
+	}
+}
+
+// WithQueueLimit creates an HTTPConfigModifier that sets how many full batches may wait to be sent. Writes never
+// block, so the oldest batches are dropped to stay within the limit when the endpoint is slow. The default is 10.
+func WithQueueLimit(n int) HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		c.queueLimit = n
+		return c
+	}
+}
+
+// WithFlushTimeout creates an HTTPConfigModifier that sets how long Flush waits for the batches to be sent, and how
+// long Close waits before cancelling the requests in flight. A timeout of 0 waits without limit. The default is 5
+// seconds.
+func WithFlushTimeout(d time.Duration) HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		c.flushTimeout = d
+		return c
+	}
+}
+
+// WithHTTPClient creates an HTTPConfigModifier that sends the requests with client. The default is a client with a
+// 30 second timeout.
+func WithHTTPClient(client *http.Client) HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		c.client = client
+		return c
+	}
+}
+
+// WithErrorHandler creates an HTTPConfigModifier that calls fn with the reason every time a batch is dropped. It is
+// called from the goroutine sending the batch, so it must not log through logr synchronously.
+func WithErrorHandler(fn func(error)) HTTPConfigModifier {
+	return func(c HTTPConfig) HTTPConfig {
+		c.onError = fn
+		return c
+	}
+}
+
+// HTTPError describes a batch that was dropped because of the response to it
+type HTTPError struct {
+	// URL the batch was sent to
+	URL string
+	// StatusCode and Status of the last response
+	StatusCode int
+	Status     string
+	// Body is the start of the response body
+	Body string
+	// Messages is the number of messages dropped
+	Messages int
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("logr: %s responded %s, dropped %d messages: %s", e.URL, e.Status, e.Messages, e.Body)
+}
+
+// httpEntry is a message waiting in a batch. Writers that group the batch, such as LokiWriter, take key and time
+// from the message, as the message itself is not retained.
+type httpEntry struct {
+	line []byte
+	key  string
+	time time.Time
+}
+
+// httpEncoder encodes a batch as a request body. For endpoints that accept a batch partially, accepted reads a
+// successful response and returns the entries to retry, the number of entries dropped and an error describing them.
+type httpEncoder struct {
+	contentType string
+	encode      func(buf *bytes.Buffer, batch []httpEntry) error
+	accepted    func(body []byte, batch []httpEntry) ([]httpEntry, int, error)
+}
+
+// HTTPWriter is an io.Writer that sends messages in batches with HTTP POST requests. A batch is sent when it is full
+// or after the flush interval, by as many senders as the concurrency allows, and waits in a bounded queue while they
+// are busy. Failed batches are retried, and dropped after a 4xx response or too many retries. Its state is reported
+// in Stats. It implements Flusher and io.Closer.
+type HTTPWriter struct {
+	c   HTTPConfig
+	url string
+	enc httpEncoder
+
+	mu     sync.Mutex
+	batch  []httpEntry
+	size   int
+	first  time.Time
+	closed bool
+
+	// queue holds the full batches waiting for a sender, oldest first
+	queue  [][]httpEntry
+	queued int
+	busy   int
+	ready  *sync.Cond
+	// drained is closed once the queue is empty and no batch is being sent
+	drained chan struct{}
+	idle    bool
+
+	hmu     sync.Mutex
+	health  WriterHealth
+	dropErr error
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewHTTPWriter creates an HTTPWriter that posts batches to url. Messages should be formatted as JSON for the
+// available body formats.
+//
+//	w, err := logr.NewHTTPWriter("https://ingest.internal/v1/logs", logr.WithGzip(), logr.WithBearerToken(token))
+//	...
+//	logr.AddWriter(w, logr.WithFormatter(logr.FormatJSON))
+//	defer logr.Close()
+func NewHTTPWriter(url string, configs ...HTTPConfigModifier) (*HTTPWriter, error) {
+	c, err := httpConfig(configs)
+	if err != nil {
+		return nil, err
+	}
+	enc := httpEncoder{contentType: "application/x-ndjson", encode: encodeNDJSON}
+	if c.format == BodyJSONArray {
+		enc = httpEncoder{contentType: "application/json", encode: encodeJSONArray}
+	}
+	return newHTTPWriter(url, c, enc), nil
+}
+
+// httpConfig returns the HTTPConfig with the defaults and the modifiers applied
+func httpConfig(configs []HTTPConfigModifier) (HTTPConfig, error) {
+	// default config
+	c := HTTPConfig{
+		header:       http.Header{},
+		batchSize:    500,
+		batchBytes:   1 << 20,
+		interval:     time.Second,
+		retries:      5,
+		minBackoff:   500 * time.Millisecond,
+		maxBackoff:   30 * time.Second,
+		concurrency:  2,
+		queueLimit:   10,
+		flushTimeout: 5 * time.Second,
+		client:       &http.Client{Timeout: 30 * time.Second},
+	}
+	// apply optional extra config modifiers
+	for _, m := range configs {
+		c = m(c)
+	}
+	// the batches are checked four times per interval
+	if c.interval > 0 && c.interval < 4*time.Nanosecond {
+		return c, fmt.Errorf("logr: flush interval of %v is too short", c.interval)
+	}
+	if c.concurrency < 1 {
+		c.concurrency = 1
+	}
+	if c.queueLimit < 1 {
+		c.queueLimit = 1
+	}
+	return c, nil
+}
+
+// newHTTPWriter creates an HTTPWriter that encodes batches with enc and starts the senders and the flush interval
+func newHTTPWriter(url string, c HTTPConfig, enc httpEncoder) *HTTPWriter {
+	w := &HTTPWriter{
+		c:       c,
+		url:     url,
+		enc:     enc,
+		health:  WriterHealth{Name: url, Healthy: true},
+		drained: make(chan struct{}),
+		idle:    true,
+		stop:    make(chan struct{}),
+	}
+	close(w.drained)
+	w.ready = sync.NewCond(&w.mu)
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	registerHealth(w)
+	w.wg.Add(c.concurrency)
+	for i := 0; i < c.concurrency; i++ {
+		go w.run()
+	}
+	if c.interval > 0 {
+		w.wg.Add(1)
+		go w.tick()
+	}
+	return w
+}
+
+// Write adds p to the current batch, sending the batch if it is full
+func (w *HTTPWriter) Write(p []byte) (int, error) {
+	return w.add(httpEntry{line: bytes.TrimRight(p, "\n")}, len(p))
+}
+
+// add appends an entry to the current batch, sending the batch if it is full
+func (w *HTTPWriter) add(e httpEntry, n int) (int, error) {
+	e.line = append([]byte(nil), e.line...)
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return 0, errors.New("logr: write to closed HTTPWriter")
+	}
+	dropped := 0
+	if len(w.batch) > 0 && w.size+len(e.line) > w.c.batchBytes {
+		dropped += w.enqueue(w.take())
+	}
+	if len(w.batch) == 0 {
+		w.first = time.Now()
+	}
+	w.batch = append(w.batch, e)
+	w.size += len(e.line)
+	if len(w.batch) >= w.c.batchSize || w.size >= w.c.batchBytes {
+		dropped += w.enqueue(w.take())
+	}
+	w.mu.Unlock()
+	w.overflow(dropped)
+	return n, nil
+}
+
+// Flush implements Flusher. It queues the current batch and waits for the queued batches to be sent, including their
+// retries, for up to the flush timeout. It returns the error of the first batch dropped while it waited.
+func (w *HTTPWriter) Flush() error {
+	w.hmu.Lock()
+	w.dropErr = nil
+	w.hmu.Unlock()
+	w.mu.Lock()
+	dropped := w.enqueue(w.take())
+	drained := w.drained
+	w.mu.Unlock()
+	w.overflow(dropped)
+	if !w.wait(drained) {
+		return fmt.Errorf("logr: timed out after %v waiting for %s", w.c.flushTimeout, w.url)
+	}
+	w.hmu.Lock()
+	defer w.hmu.Unlock()
+	return w.dropErr
+}
+
+// Close sends the current and the queued batches without retries, waits for them and removes the HTTPWriter from
+// Stats. Requests still in flight after the flush timeout are cancelled.
+func (w *HTTPWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	dropped := w.enqueue(w.take())
+	w.closed = true
+	w.ready.Broadcast()
+	w.mu.Unlock()
+	w.overflow(dropped)
+	// stopping ends the retries, so every queued batch gets a single attempt
+	close(w.stop)
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	if !w.wait(done) {
+		w.cancel()
+		<-done
+	}
+	w.cancel()
+	unregisterHealth(w)
+	return nil
+}
+
+// wait waits for done for up to the flush timeout, reporting whether it was closed in time
+func (w *HTTPWriter) wait(done <-chan struct{}) bool {
+	if w.c.flushTimeout <= 0 {
+		<-done
+		return true
+	}
+	t := time.NewTimer(w.c.flushTimeout)
+	defer t.Stop()
+	select {
+	case <-done:
+		return true
+	case <-t.C:
+		return false
+	}
+}
+
+// Health implements HealthReporter
+func (w *HTTPWriter) Health() WriterHealth {
+	w.mu.Lock()
+	size := w.size + w.queued
+	w.mu.Unlock()
+	w.hmu.Lock()
+	defer w.hmu.Unlock()
+	h := w.health
+	h.Buffered = size
+	return h
+}
+
+// tick sends the current batch once it is older than the flush interval
+func (w *HTTPWriter) tick() {
+	defer w.wg.Done()
+	t := time.NewTicker(w.c.interval / 4)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case now := <-t.C:
+			dropped := 0
+			w.mu.Lock()
+			if len(w.batch) > 0 && now.Sub(w.first) >= w.c.interval {
+				dropped = w.enqueue(w.take())
+			}
+			w.mu.Unlock()
+			w.overflow(dropped)
+		}
+	}
+}
+
+// take removes the current batch to be sent. It is called with mu held.
+func (w *HTTPWriter) take() []httpEntry {
+	batch := w.batch
+	w.batch = nil
+	w.size = 0
+	return batch
+}
+
+// enqueue adds a batch to the queue for the senders, dropping the oldest batches to stay within the queue limit. It
+// returns the number of messages dropped, which are recorded with overflow once mu is released. It is called with mu
+// held.
+func (w *HTTPWriter) enqueue(batch []httpEntry) int {
+	if len(batch) == 0 {
+		return 0
+	}
+	if w.idle {
+		w.drained = make(chan struct{})
+		w.idle = false
+	}
+	w.queue = append(w.queue, batch)
+	w.queued += batchBytes(batch)
+	dropped := 0
+	for len(w.queue) > w.c.queueLimit {
+		dropped += len(w.queue[0])
+		w.queued -= batchBytes(w.queue[0])
+		w.queue[0] = nil
+		w.queue = w.queue[1:]
+	}
+	w.ready.Signal()
+	return dropped
+}
+
+// overflow records the messages dropped because the queue was full
+func (w *HTTPWriter) overflow(dropped int) {
+	if dropped > 0 {
+		w.record(fmt.Errorf("logr: %s is too slow, dropped %d queued messages", w.url, dropped), dropped)
+	}
+}
+
+// run sends the queued batches in order until the HTTPWriter is closed and the queue is empty
+func (w *HTTPWriter) run() {
+	defer w.wg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for {
+		for len(w.queue) == 0 && !w.closed {
+			w.ready.Wait()
+		}
+		if len(w.queue) == 0 {
+			return
+		}
+		batch := w.queue[0]
+		w.queue[0] = nil
+		w.queue = w.queue[1:]
+		w.queued -= batchBytes(batch)
+		w.busy++
+		w.mu.Unlock()
+		w.deliver(batch)
+		w.mu.Lock()
+		if w.busy--; w.busy == 0 && len(w.queue) == 0 && !w.idle {
+			close(w.drained)
+			w.idle = true
+		}
+	}
+}
+
+// batchBytes returns the size of the messages in a batch
+func batchBytes(batch []httpEntry) int {
+	n := 0
+	for _, e := range batch {
+		n += len(e.line)
+	}
+	return n
+}
+
+// deliver posts a batch, retrying it until it is accepted, rejected or out of retries
+func (w *HTTPWriter) deliver(batch []httpEntry) {
+	backoff := w.c.minBackoff
+	for attempt := 0; ; attempt++ {
+		retry, dropped, delay, err := w.post(batch)
+		if err == nil {
+			w.record(nil, 0)
+			return
+		}
+		if attempt >= w.c.retries {
+			dropped += len(retry)
+			retry = nil
+		}
+		w.record(err, dropped)
+		if len(retry) == 0 {
+			return
+		}
+		batch = retry
+		if delay == 0 {
+			delay = backoff
+		} else if delay > w.c.maxBackoff {
+			// a server asking to wait longer than the backoff allows is not left to stall the writer
+			delay = w.c.maxBackoff
+		}
+		if backoff *= 2; backoff > w.c.maxBackoff {
+			backoff = w.c.maxBackoff
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-w.stop:
+			t.Stop()
+			w.record(fmt.Errorf("logr: HTTPWriter closed while retrying: %w", err), len(batch))
+			return
+		}
+	}
+}
+
+// post sends a batch once. It returns the entries that may be retried, the number of entries dropped and the delay
+// requested by the server, which is zero when the backoff applies.
+func (w *HTTPWriter) post(batch []httpEntry) ([]httpEntry, int, time.Duration, error) {
+	var body bytes.Buffer
+	if err := w.encodeBody(&body, batch); err != nil {
+		return nil, len(batch), 0, err
+	}
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, w.url, &body)
+	if err != nil {
+		return nil, len(batch), 0, err
+	}
+	for k, v := range w.c.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", w.enc.contentType)
+	if w.c.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := w.c.client.Do(req)
+	if err != nil {
+		return batch, 0, 0, err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		if w.enc.accepted == nil {
+			return nil, 0, 0, nil
+		}
+		retry, dropped, err := w.enc.accepted(b, batch)
+		return retry, dropped, 0, err
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode >= 500:
+		delay := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return batch, 0, delay, w.httpError(resp, b, len(batch))
+	default:
+		return nil, len(batch), 0, w.httpError(resp, b, len(batch))
+	}
+}
+
+// encodeBody encodes a batch, compressing it if configured
+func (w *HTTPWriter) encodeBody(buf *bytes.Buffer, batch []httpEntry) error {
+	if !w.c.gzip {
+		return w.enc.encode(buf, batch)
+	}
+	var plain bytes.Buffer
+	if err := w.enc.encode(&plain, batch); err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(buf)
+	if _, err := gz.Write(plain.Bytes()); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// httpError creates the HTTPError for a response
+func (w *HTTPWriter) httpError(resp *http.Response, body []byte, messages int) *HTTPError {
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	return &HTTPError{
+		URL:        w.url,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       string(bytes.TrimSpace(body)),
+		Messages:   messages,
+	}
+}
+
+// record updates the health after an attempt. dropped is the number of messages dropped, which are reported to the
+// error handler.
+func (w *HTTPWriter) record(err error, dropped int) {
+	w.hmu.Lock()
+	if err == nil {
+		w.health.Healthy = true
+	} else {
+		w.health.Healthy = false
+		w.health.LastError = err.Error()
+		w.health.LastErrorTime = time.Now()
+		w.health.Dropped += uint64(dropped)
+		if dropped > 0 && w.dropErr == nil {
+			w.dropErr = err
+		}
+	}
+	w.hmu.Unlock()
+	if err != nil && dropped > 0 && w.c.onError != nil {
+		w.c.onError(err)
+	}
+}
+
+// retryAfter returns the delay requested by a Retry-After header, in seconds or as a date, or zero if there is none
+func retryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(v); err == nil {
+		if s > 0 {
+			return time.Duration(s) * time.Second
+		}
+		return 0
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// encodeNDJSON writes one entry per line
+func encodeNDJSON(buf *bytes.Buffer, batch []httpEntry) error {
+	for _, e := range batch {
+		buf.Write(e.line)
+		buf.WriteByte('\n')
+	}
+	return nil
+}
+
+// encodeJSONArray writes the entries as the elements of a JSON array
+func encodeJSONArray(buf *bytes.Buffer, batch []httpEntry) error {
+	buf.WriteByte('[')
+	for i, e := range batch {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(e.line)
+	}
+	buf.WriteByte(']')
+	return nil
+}
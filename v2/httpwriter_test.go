@@ -0,0 +1,399 @@
+package logr
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// httpRecorder records the requests received by a test server and responds with the queued statuses
+type httpRecorder struct {
+	mu       sync.Mutex
+	bodies   []string
+	headers  []http.Header
+	statuses []int
+	retry    string
+}
+
+func (h *httpRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body = gz
+	}
+	b, _ := io.ReadAll(body)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.bodies = append(h.bodies, string(b))
+	h.headers = append(h.headers, r.Header)
+	if len(h.statuses) > 0 {
+		status := h.statuses[0]
+		h.statuses = h.statuses[1:]
+		if h.retry != "" {
+			w.Header().Set("Retry-After", h.retry)
+		}
+		http.Error(w, "status "+http.StatusText(status), status)
+	}
+}
+
+func (h *httpRecorder) requests() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.bodies...)
+}
+
+func TestHTTPWriterBatches(t *testing.T) {
+	rec := &httpRecorder{}
+	s := httptest.NewServer(rec)
+	defer s.Close()
+
+	tests := []struct {
+		name    string
+		configs []HTTPConfigModifier
+		want    []string
+		ctype   string
+	}{
+		{
+			name:    "ndjson by size",
+			configs: []HTTPConfigModifier{WithBatchSize(2)},
+			want:    []string{"{\"n\":1}\n{\"n\":2}\n", "{\"n\":3}\n"},
+			ctype:   "application/x-ndjson",
+		},
+		{
+			name:    "json array by bytes",
+			configs: []HTTPConfigModifier{WithBodyFormat(BodyJSONArray), WithBatchBytes(14), WithGzip()},
+			want:    []string{`[{"n":1},{"n":2}]`, `[{"n":3}]`},
+			ctype:   "application/json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec.bodies, rec.headers = nil, nil
+			w, err := NewHTTPWriter(s.URL, append(tt.configs, WithFlushInterval(0), WithBearerToken("t0k"))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, line := range []string{`{"n":1}` + "\n", `{"n":2}` + "\n", `{"n":3}` + "\n"} {
+				if _, err := w.Write([]byte(line)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			// batches are sent concurrently, so they may arrive in any order
+			got := rec.requests()
+			sort.Strings(got)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("expected %q. Got: %q", tt.want, got)
+			}
+			h := rec.headers[0]
+			if h.Get("Content-Type") != tt.ctype || h.Get("Authorization") != "Bearer t0k" {
+				t.Errorf("unexpected headers %v", h)
+			}
+		})
+	}
+}
+
+func TestHTTPWriterFlushInterval(t *testing.T) {
+	if _, err := NewHTTPWriter("http://localhost", WithFlushInterval(3*time.Nanosecond)); err == nil {
+		t.Error("expected a flush interval under 4ns to be rejected")
+	}
+
+	rec := &httpRecorder{}
+	s := httptest.NewServer(rec)
+	defer s.Close()
+	w, err := NewHTTPWriter(s.URL, WithFlushInterval(20*time.Millisecond), WithBasicAuth("user", "pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("{}\n"))
+	for i := 0; i < 500 && len(rec.requests()) == 0; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := rec.requests(); len(got) != 1 || got[0] != "{}\n" {
+		t.Errorf("expected the batch to be sent after the interval. Got: %q", got)
+	}
+	if user, pass, ok := (&http.Request{Header: rec.headers[0]}).BasicAuth(); !ok || user != "user" || pass != "pass" {
+		t.Errorf("expected basic auth. Got: %v", rec.headers[0])
+	}
+}
+
+func TestHTTPWriterRetries(t *testing.T) {
+	rec := &httpRecorder{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}, retry: "0"}
+	s := httptest.NewServer(rec)
+	defer s.Close()
+	w, err := NewHTTPWriter(s.URL, WithFlushInterval(0), WithRetryBackoff(time.Millisecond, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("{}\n"))
+	w.Flush()
+	if got := rec.requests(); len(got) != 3 {
+		t.Errorf("expected the batch to be retried twice. Got: %q", got)
+	}
+	if h := w.Health(); !h.Healthy || h.Dropped != 0 || !strings.Contains(h.LastError, "429") {
+		t.Errorf("expected the writer to recover. Got: %+v", h)
+	}
+
+	rec.statuses = []int{http.StatusInternalServerError, http.StatusInternalServerError}
+	w.c.retries = 1
+	w.Write([]byte("{}\n"))
+	w.Flush()
+	if h := w.Health(); h.Healthy || h.Dropped != 1 {
+		t.Errorf("expected the batch to be dropped after the retries. Got: %+v", h)
+	}
+}
+
+func TestHTTPWriterDropsRejectedBatches(t *testing.T) {
+	rec := &httpRecorder{statuses: []int{http.StatusBadRequest}}
+	s := httptest.NewServer(rec)
+	defer s.Close()
+	var dropped error
+	w, err := NewHTTPWriter(s.URL, WithFlushInterval(0), WithErrorHandler(func(err error) { dropped = err }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("{}\n"))
+	w.Write([]byte("{}\n"))
+	w.Flush()
+	var herr *HTTPError
+	if !errors.As(dropped, &herr) || herr.StatusCode != http.StatusBadRequest || herr.Messages != 2 ||
+		herr.Body != "status Bad Request" {
+		t.Fatalf("expected an HTTPError with the response. Got: %v", dropped)
+	}
+	if got := rec.requests(); len(got) != 1 {
+		t.Errorf("expected a 4xx response not to be retried. Got: %q", got)
+	}
+	if h := w.Health(); h.Healthy || h.Dropped != 2 || h.LastError != herr.Error() {
+		t.Errorf("expected the drop in the health. Got: %+v", h)
+	}
+}
+
+func TestHTTPWriterConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	active, peak, requests := 0, 0, 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		if active++; active > peak {
+			peak = active
+		}
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		active--
+		mu.Unlock()
+	}))
+	defer s.Close()
+	w, err := NewHTTPWriter(s.URL, WithFlushInterval(0), WithBatchSize(1), WithConcurrency(2), WithQueueLimit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("{}\n"))
+	w.Write([]byte("{}\n"))
+	for i := 0; i < 500; i++ {
+		mu.Lock()
+		n := active
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// both senders are busy, so the third batch is dropped to keep two in the queue
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			w.Write([]byte("{}\n"))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected writes not to block while the batches are in flight")
+	}
+	if h := w.Health(); h.Buffered != 4 || h.Dropped != 1 || !strings.Contains(h.LastError, "dropped 1 queued") {
+		t.Errorf("expected two queued batches and one dropped. Got: %+v", h)
+	}
+	close(release)
+	w.Flush()
+	mu.Lock()
+	defer mu.Unlock()
+	if peak != 2 || requests != 4 {
+		t.Errorf("expected 4 requests with at most 2 in flight. Got %d with %d", requests, peak)
+	}
+}
+
+func TestHTTPWriterSlowEndpoint(t *testing.T) {
+	hang := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-hang }))
+	defer slow.Close()
+	rec := &httpRecorder{}
+	s := httptest.NewServer(rec)
+	defer s.Close()
+
+	a, err := NewHTTPWriter(slow.URL, WithFlushInterval(0), WithBatchSize(1), WithConcurrency(1), WithQueueLimit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	defer close(hang)
+	b, err := NewHTTPWriter(s.URL, WithFlushInterval(0), WithBatchSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	defer AddWriter(a, WithFormatter(FormatJSON))()
+	defer AddWriter(b, WithFormatter(FormatJSON))()
+
+	for i := 0; i < 20; i++ {
+		Infof("TestHTTPWriterSlowEndpoint %d", i)
+	}
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(time.Millisecond) {
+		if strings.Contains(strings.Join(rec.requests(), ""), "TestHTTPWriterSlowEndpoint 19") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected a hanging endpoint not to hold up the other writers")
+		}
+	}
+	if h := a.Health(); h.Dropped == 0 {
+		t.Errorf("expected the hanging endpoint to drop messages. Got: %+v", h)
+	}
+}
+
+func TestHTTPWriterFlushTimeout(t *testing.T) {
+	hang := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-hang }))
+	defer s.Close()
+	defer close(hang)
+	w, err := NewHTTPWriter(s.URL, WithFlushInterval(0), WithFlushTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Write([]byte("{}\n"))
+	if err := w.Flush(); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected Flush to time out. Got: %v", err)
+	}
+	start := time.Now()
+	w.Close()
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("expected Close to cancel the request in flight. Took %v", d)
+	}
+	if h := w.Health(); h.Dropped != 1 {
+		t.Errorf("expected the cancelled batch to be dropped. Got: %+v", h)
+	}
+}
+
+func TestHTTPWriterClampsRetryAfter(t *testing.T) {
+	rec := &httpRecorder{statuses: []int{http.StatusServiceUnavailable}, retry: "3600"}
+	s := httptest.NewServer(rec)
+	defer s.Close()
+	w, err := NewHTTPWriter(s.URL, WithFlushInterval(0), WithRetryBackoff(time.Millisecond, 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("{}\n"))
+	start := time.Now()
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > time.Second || len(rec.requests()) != 2 {
+		t.Errorf("expected the retry after the maximum backoff. Got %d requests in %v", len(rec.requests()), d)
+	}
+}
+
+func TestHTTPWriterFlushReportsDrops(t *testing.T) {
+	rec := &httpRecorder{statuses: []int{http.StatusBadRequest}}
+	s := httptest.NewServer(rec)
+	defer s.Close()
+	w, err := NewHTTPWriter(s.URL, WithFlushInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("{}\n"))
+	var herr *HTTPError
+	if err := w.Flush(); !errors.As(err, &herr) || herr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected Flush to return the drop. Got: %v", err)
+	}
+	w.Write([]byte("{}\n"))
+	if err := w.Flush(); err != nil {
+		t.Errorf("expected the next Flush to succeed. Got: %v", err)
+	}
+}
+
+func TestHTTPWriterCloseStopsRetries(t *testing.T) {
+	rec := &httpRecorder{statuses: []int{503, 503, 503}}
+	s := httptest.NewServer(rec)
+	defer s.Close()
+	w, err := NewHTTPWriter(s.URL, WithFlushInterval(0), WithBatchSize(1), WithConcurrency(1),
+		WithRetryBackoff(time.Hour, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first batch waits to be retried in the only slot while the second is the current batch at Close
+	w.Write([]byte("{}\n"))
+	for len(rec.requests()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	w.c.batchSize = 2
+	w.Write([]byte("{}\n"))
+	done := make(chan struct{})
+	go func() {
+		w.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Close not to wait for the retry backoff")
+	}
+	if h := w.Health(); h.Dropped != 2 {
+		t.Errorf("expected both batches to be dropped. Got: %+v", h)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"3":                             3 * time.Second,
+		"-1":                            0,
+		"Sun, 18 Oct 2026 12:00:10 GMT": 10 * time.Second,
+		"Sun, 18 Oct 2026 11:00:00 GMT": 0,
+		"soon":                          0,
+	}
+	for v, want := range tests {
+		if got := retryAfter(v, now); got != want {
+			t.Errorf("expected %q to be %v. Got: %v", v, want, got)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package logr
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// JSONField identifies a top-level Message field in the output of a json Formatter
+type JSONField int
+
+// Available json fields
+const (
+	JSONFieldType JSONField = iota
+	JSONFieldTime
+	JSONFieldCode
+	JSONFieldDesc
+	JSONFieldMeta
+)
+
+// TypeEncoding defines how the Type of a Message is written by a json Formatter
+type TypeEncoding int
+
+// Available type encodings
+const (
+	TypeAsName     TypeEncoding = iota // the descriptive string, e.g. "warning"
+	TypeAsRune                         // the rune, e.g. "W"
+	TypeAsSeverity                     // the numeric syslog severity, e.g. 4
+)
+
+// MetaCollision defines what happens when flattened meta data has the same key as a top-level field
+type MetaCollision int
+
+// Available meta collision policies
+const (
+	MetaCollisionPrefix    MetaCollision = iota // the meta data key is prefixed with "meta."
+	MetaCollisionOverwrite                      // the meta data value replaces the top-level field
+	MetaCollisionSkip                           // the meta data value is left out
+)
+
+type JSONConfig struct {
+	names        [5]string
+	flatten      bool
+	collision    MetaCollision
+	typeEncoding TypeEncoding
+	timeLayout   string
+	indent       string
+	static       Meta
+}
+
+type JSONConfigModifier func(c JSONConfig) JSONConfig
+
+// WithJSONFieldName creates a JSONConfigModifier that renames a top-level field. An empty name leaves the field out.
+// The default names are type, time, code, description and metadata. Unknown fields are ignored.
+func WithJSONFieldName(f JSONField, name string) JSONConfigModifier {
+	return func(c JSONConfig) JSONConfig {
+		if f < 0 || int(f) >= len(c.names) {
+			return c
+		}
+		c.names[f] = name
+		return c
+	}
+}
+
+// WithFlattenedMeta creates a JSONConfigModifier that writes the meta data keys at the top level instead of in a
+// nested object. Keys that collide with a top-level field or a static field are handled according to policy.
+func WithFlattenedMeta(policy MetaCollision) JSONConfigModifier {
+	return func(c JSONConfig) JSONConfig {
+		c.flatten = true
+		c.collision = policy
+		return c
+	}
+}
+
+// WithTypeEncoding creates a JSONConfigModifier that sets how the Type is written. The default is TypeAsName.
+func WithTypeEncoding(e TypeEncoding) JSONConfigModifier {
+	return func(c JSONConfig) JSONConfig {
+		c.typeEncoding = e
+		return c
+	}
+}
+
+// WithTimeLayout creates a JSONConfigModifier that writes the time the message was logged formatted with layout,
+// e.g. time.RFC3339Nano, instead of Message.Time.
+func WithTimeLayout(layout string) JSONConfigModifier {
+	return func(c JSONConfig) JSONConfig {
+		c.timeLayout = layout
+		return c
+	}
+}
+
+// WithIndent creates a JSONConfigModifier that writes pretty json, indenting nested values with indent
+func WithIndent(indent string) JSONConfigModifier {
+	return func(c JSONConfig) JSONConfig {
+		c.indent = indent
+		return c
+	}
+}
+
+// WithStaticFields creates a JSONConfigModifier that adds the given fields to the top level of every message
+func WithStaticFields(fields Meta) JSONConfigModifier {
+	return func(c JSONConfig) JSONConfig {
+		static := c.static.Copy()
+		for k, v := range fields {
+			static.With(k, v)
+		}
+		c.static = static
+		return c
+	}
+}
+
+// jsonField is a key and its json encoded value
+type jsonField struct {
+	key   string
+	value []byte
+}
+
+// JSONFormatter converts a Message to json. It implements AppendFormatter and its Format method is a Formatter.
+type JSONFormatter struct {
+	c        JSONConfig
+	reserved map[string]bool
+	static   []jsonField
+}
+
+var defaultJSON = NewJSONFormatter()
+
+// FormatJSON is a Formatter that converts a Message to json. It is the default configuration of NewJSONFormatter.
+func FormatJSON(m *Message) []byte {
+	return defaultJSON.Format(m)
+}
+
+// AppendJSON is the AppendFormatter equivalent of FormatJSON
+func AppendJSON(dst []byte, m *Message) []byte {
+	return defaultJSON.AppendFormat(dst, m)
+}
+
+// NewJSONFormatter creates a JSONFormatter. The top-level fields are written in a fixed order: type, time, code,
+// description, metadata, the static fields and then any flattened meta data in key order.
+//
+//	AddWriter(os.Stdout, WithAppendFormatter(NewJSONFormatter(WithFlattenedMeta(MetaCollisionPrefix))))
+func NewJSONFormatter(configs ...JSONConfigModifier) *JSONFormatter {
+	// default config
+	c := JSONConfig{
+		names: [5]string{"type", "time", "code", "description", "metadata"},
+	}
+	// apply optional extra config modifiers
+	for _, m := range configs {
+		c = m(c)
+	}
+	if c.flatten {
+		c.names[JSONFieldMeta] = ""
+	}
+
+	f := &JSONFormatter{
+		c:        c,
+		reserved: map[string]bool{},
+	}
+	for _, n := range c.names {
+		if n != "" {
+			f.reserved[n] = true
+		}
+	}
+	for k, v := range c.static {
+		f.reserved[k] = true
+		f.static = append(f.static, jsonField{key: k, value: appendJSONValue(nil, v)})
+	}
+	sort.Slice(f.static, func(i, j int) bool {
+		return f.static[i].key < f.static[j].key
+	})
+	return f
+}
+
+// Format implements Formatter
+func (f *JSONFormatter) Format(m *Message) []byte {
+	return f.AppendFormat(make([]byte, 0, 256), m)
+}
+
+// AppendFormat implements AppendFormatter
+func (f *JSONFormatter) AppendFormat(dst []byte, m *Message) []byte {
+	start := len(dst)
+	dst = append(dst, '{')
+	n := 0
+	key := func(k string) {
+		if n > 0 {
+			dst = append(dst, ',')
+		}
+		n++
+		dst = appendJSONString(dst, k)
+		dst = append(dst, ':')
+	}
+	field := func(jf JSONField) bool {
+		name := f.c.names[jf]
+		if name == "" || f.overwritten(m, name) {
+			return false
+		}
+		key(name)
+		return true
+	}
+
+	if field(JSONFieldType) {
+		dst = f.appendType(dst, m.Type)
+	}
+	if field(JSONFieldTime) {
+		if f.c.timeLayout != "" {
+			dst = appendJSONTime(dst, messageTime(m), f.c.timeLayout)
+		} else {
+			dst = appendJSONString(dst, m.Time)
+		}
+	}
+	if field(JSONFieldCode) {
+		dst = appendJSONString(dst, m.Code)
+	}
+	if field(JSONFieldDesc) {
+		dst = appendJSONString(dst, m.Desc)
+	}
+	if len(m.Meta) > 0 && field(JSONFieldMeta) {
+		dst = appendJSONObject(dst, m.Meta)
+	}
+	for _, sf := range f.static {
+		if !f.overwritten(m, sf.key) {
+			key(sf.key)
+			dst = append(dst, sf.value...)
+		}
+	}
+
+	if f.c.flatten && len(m.Meta) > 0 {
+		var arr [16]string
+		for _, k := range sortedKeys(arr[:0], m.Meta) {
+			if f.reserved[k] {
+				switch f.c.collision {
+				case MetaCollisionSkip:
+					continue
+				case MetaCollisionPrefix:
+					key("meta." + k)
+				default:
+					key(k)
+				}
+			} else {
+				key(k)
+			}
+			dst = appendJSONValue(dst, m.Meta[k])
+		}
+	}
+	dst = append(dst, '}')
+
+	if f.c.indent != "" {
+		out := &bytes.Buffer{}
+		if err := json.Indent(out, dst[start:], "", f.c.indent); err == nil {
+			dst = append(dst[:start], out.Bytes()...)
+		}
+	}
+	return append(dst, '\n')
+}
+
+// appendJSONTime appends t formatted with layout as a json string. The literal text of a layout may need escaping,
+// which is only done when it does.
+func appendJSONTime(dst []byte, t time.Time, layout string) []byte {
+	start := len(dst)
+	dst = append(dst, '"')
+	dst = t.AppendFormat(dst, layout)
+	for _, b := range dst[start+1:] {
+		if b < ' ' || b >= utf8.RuneSelf || b == '"' || b == '\\' || b == '<' || b == '>' || b == '&' {
+			return appendJSONString(dst[:start], string(dst[start+1:]))
+		}
+	}
+	return append(dst, '"')
+}
+
+// overwritten reports whether a top-level field is replaced by flattened meta data
+func (f *JSONFormatter) overwritten(m *Message, key string) bool {
+	if !f.c.flatten || f.c.collision != MetaCollisionOverwrite {
+		return false
+	}
+	_, ok := m.Meta[key]
+	return ok
+}
+
+// appendType appends the encoded Type
+func (f *JSONFormatter) appendType(dst []byte, t Type) []byte {
+	switch f.c.typeEncoding {
+	case TypeAsRune:
+		return appendJSONString(dst, t.Rune())
+	case TypeAsSeverity:
+		return strconv.AppendInt(dst, int64(t.Severity()), 10)
+	default:
+		return appendJSONString(dst, t.String())
+	}
+}
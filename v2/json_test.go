@@ -0,0 +1,98 @@
+package logr
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func jsonTestMessage() *Message {
+	ts := time.Date(2026, 10, 17, 14, 5, 6, 0, time.UTC)
+	return &Message{
+		Type:      I,
+		Time:      ts.Format(TimeFormat),
+		Code:      "abc123",
+		Desc:      "<hello>",
+		Timestamp: ts,
+		Meta:      MetaData{"user": "u1", "code": "c1", "nested": Meta{"a": 1}},
+	}
+}
+
+func TestFormatJSON(t *testing.T) {
+	for _, m := range []*Message{jsonTestMessage(), {Type: E, Desc: "no meta\b\f\x01"}} {
+		want, _ := json.Marshal(m)
+		if got := string(FormatJSON(m)); got != string(want)+"\n" {
+			t.Errorf("FormatJSON() = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestNewJSONFormatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []JSONConfigModifier
+		want    string
+	}{
+		{
+			name: "Renamed and omitted fields",
+			configs: []JSONConfigModifier{
+				WithJSONFieldName(JSONFieldDesc, "msg"),
+				WithJSONFieldName(JSONFieldCode, ""),
+				WithJSONFieldName(JSONFieldMeta, "fields"),
+				WithTypeEncoding(TypeAsRune),
+				WithTimeLayout(time.RFC3339),
+			},
+			want: `{"type":"I","time":"2026-10-17T14:05:06Z","msg":"\u003chello\u003e","fields":{"code":"c1","nested":{"a":1},"user":"u1"}}`,
+		},
+		{
+			name: "Flattened with prefixed collisions",
+			configs: []JSONConfigModifier{
+				WithFlattenedMeta(MetaCollisionPrefix),
+				WithStaticFields(Meta{"service": "api", "user": "static"}),
+				WithTypeEncoding(TypeAsSeverity),
+			},
+			want: `{"type":6,"time":"Oct 17 2026 14:05:06","code":"abc123","description":"\u003chello\u003e","service":"api","user":"static","meta.code":"c1","nested":{"a":1},"meta.user":"u1"}`,
+		},
+		{
+			name: "Flattened with overwritten collisions",
+			configs: []JSONConfigModifier{
+				WithFlattenedMeta(MetaCollisionOverwrite),
+				WithStaticFields(Meta{"user": "static"}),
+			},
+			want: `{"type":"info","time":"Oct 17 2026 14:05:06","description":"\u003chello\u003e","code":"c1","nested":{"a":1},"user":"u1"}`,
+		},
+		{
+			name: "Flattened with skipped collisions",
+			configs: []JSONConfigModifier{
+				WithFlattenedMeta(MetaCollisionSkip),
+			},
+			want: `{"type":"info","time":"Oct 17 2026 14:05:06","code":"abc123","description":"\u003chello\u003e","nested":{"a":1},"user":"u1"}`,
+		},
+		{
+			name: "Escaped time layout and unknown field",
+			configs: []JSONConfigModifier{
+				WithTimeLayout(`"2006" \ <01>`),
+				WithJSONFieldName(JSONField(-1), "x"),
+				WithJSONFieldName(JSONField(5), "x"),
+				WithJSONFieldName(JSONFieldMeta, ""),
+			},
+			want: `{"type":"info","time":"\"2026\" \\ \u003c10\u003e","code":"abc123","description":"\u003chello\u003e"}`,
+		},
+		{
+			name: "Pretty",
+			configs: []JSONConfigModifier{
+				WithJSONFieldName(JSONFieldTime, ""),
+				WithJSONFieldName(JSONFieldMeta, ""),
+				WithIndent("  "),
+			},
+			want: "{\n  \"type\": \"info\",\n  \"code\": \"abc123\",\n  \"description\": \"\\u003chello\\u003e\"\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(NewJSONFormatter(tt.configs...).Format(jsonTestMessage())); got != tt.want+"\n" {
+				t.Errorf("Formatter() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package logr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONValue appends the json encoding of v to dst. Common meta data value types are encoded directly, producing
+// the same output as encoding/json, and anything else falls back to json.Marshal.
+func appendJSONValue(dst []byte, v any) []byte {
+	switch t := v.(type) {
+	case nil:
+		return append(dst, "null"...)
+	case string:
+		return appendJSONString(dst, t)
+	case bool:
+		return strconv.AppendBool(dst, t)
+	case int:
+		return strconv.AppendInt(dst, int64(t), 10)
+	case int8:
+		return strconv.AppendInt(dst, int64(t), 10)
+	case int16:
+		return strconv.AppendInt(dst, int64(t), 10)
+	case int32:
+		return strconv.AppendInt(dst, int64(t), 10)
+	case int64:
+		return strconv.AppendInt(dst, t, 10)
+	case uint:
+		return strconv.AppendUint(dst, uint64(t), 10)
+	case uint8:
+		return strconv.AppendUint(dst, uint64(t), 10)
+	case uint16:
+		return strconv.AppendUint(dst, uint64(t), 10)
+	case uint32:
+		return strconv.AppendUint(dst, uint64(t), 10)
+	case uint64:
+		return strconv.AppendUint(dst, t, 10)
+	case float32:
+		return appendJSONFloat(dst, float64(t), 32)
+	case float64:
+		return appendJSONFloat(dst, t, 64)
+	case time.Duration:
+		return strconv.AppendInt(dst, int64(t), 10)
+	case time.Time:
+		dst = append(dst, '"')
+		dst = t.AppendFormat(dst, time.RFC3339Nano)
+		return append(dst, '"')
+	case Type:
+		return appendJSONString(dst, t.String())
+	case Meta:
+		return appendJSONObject(dst, t)
+	case MetaData:
+		return appendJSONObject(dst, t)
+	case map[string]any:
+		return appendJSONObject(dst, t)
+	case map[string]string:
+		return appendJSONStringObject(dst, t)
+	case []any:
+		if t == nil {
+			return append(dst, "null"...)
+		}
+		dst = append(dst, '[')
+		for i, e := range t {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONValue(dst, e)
+		}
+		return append(dst, ']')
+	case []string:
+		if t == nil {
+			return append(dst, "null"...)
+		}
+		dst = append(dst, '[')
+		for i, e := range t {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, e)
+		}
+		return append(dst, ']')
+	default:
+		r, err := json.Marshal(v)
+		if err != nil {
+			fmt.Printf("failed to marshal Message in logr.FormatJSON: %v\n", err)
+			return append(dst, "null"...)
+		}
+		return append(dst, r...)
+	}
+}
+
+// appendJSONObject appends a json object with the entries of m in key order
+func appendJSONObject(dst []byte, m map[string]any) []byte {
+	if m == nil {
+		return append(dst, "null"...)
+	}
+	var arr [16]string
+	keys := sortedKeys(arr[:0], m)
+	dst = append(dst, '{')
+	for i, k := range keys {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, k)
+		dst = append(dst, ':')
+		dst = appendJSONValue(dst, m[k])
+	}
+	return append(dst, '}')
+}
+
+// appendJSONStringObject appends a json object with the entries of m in key order
+func appendJSONStringObject(dst []byte, m map[string]string) []byte {
+	if m == nil {
+		return append(dst, "null"...)
+	}
+	var arr [16]string
+	keys := arr[:0]
+	for k := range m {
+		keys = insertSorted(keys, k)
+	}
+	dst = append(dst, '{')
+	for i, k := range keys {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, k)
+		dst = append(dst, ':')
+		dst = appendJSONString(dst, m[k])
+	}
+	return append(dst, '}')
+}
+
+// sortedKeys appends the keys of m to keys in order
+func sortedKeys(keys []string, m map[string]any) []string {
+	for k := range m {
+		keys = insertSorted(keys, k)
+	}
+	return keys
+}
+
+// insertSorted inserts s into the sorted slice keys. Meta data rarely has many keys so an insertion sort into a
+// caller provided array avoids allocating.
+func insertSorted(keys []string, s string) []string {
+	keys = append(keys, s)
+	i := len(keys) - 1
+	for ; i > 0 && keys[i-1] > s; i-- {
+		keys[i] = keys[i-1]
+	}
+	keys[i] = s
+	return keys
+}
+
+// appendJSONFloat appends f the way encoding/json does
+func appendJSONFloat(dst []byte, f float64, bits int) []byte {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		fmt.Printf("failed to marshal Message in logr.FormatJSON: unsupported value: %v\n", f)
+		return append(dst, "null"...)
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
+
+// appendJSONString appends s as a quoted json string, escaping HTML characters the way encoding/json does
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
@@ -0,0 +1,432 @@
+package logr
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// TruncatedKey is the meta data key listing the fields of a message that were truncated by Limits
+const TruncatedKey = "_truncated"
+
+// maxMarkerSize is the size of the longest marker truncationMarker returns
+const maxMarkerSize = len("…(truncated 1023TB)")
+
+// minTruncatedSize is the size fields are never shrunk below to keep a message within its maximum size
+const minTruncatedSize = 64
+
+type LimitsConfig struct {
+	desc    int
+	keys    int
+	value   int
+	depth   int
+	message int
+}
+
+type LimitsConfigModifier func(c LimitsConfig) LimitsConfig
+
+// WithMaxDescLength creates a LimitsConfigModifier that sets the maximum length of the description in bytes. The
+// default is 64KB.
+func WithMaxDescLength(n int) LimitsConfigModifier {
+	return func(c LimitsConfig) LimitsConfig {
+		c.desc = n
+		return c
+	}
+}
+
+// WithMaxMetaKeys creates a LimitsConfigModifier that sets the maximum number of keys in the meta data and in each
+// nested map. The keys that sort first are kept. The default is 100.
+func WithMaxMetaKeys(n int) LimitsConfigModifier {
+	return func(c LimitsConfig) LimitsConfig {
+		c.keys = n
+		return c
+	}
+}
+
+// WithMaxValueSize creates a LimitsConfigModifier that sets the maximum size in bytes of each meta data value,
+// rendered as text. Values over the limit are replaced with their truncated text. The default is 16KB.
+func WithMaxValueSize(n int) LimitsConfigModifier {
+	return func(c LimitsConfig) LimitsConfig {
+		c.value = n
+		return c
+	}
+}
+
+// WithMaxDepth creates a LimitsConfigModifier that sets how many levels of maps and slices may be nested in the meta
+// data. Deeper values are replaced with a truncation marker. The default is 5.
+func WithMaxDepth(n int) LimitsConfigModifier {
+	return func(c LimitsConfig) LimitsConfig {
+		c.depth = n
+		return c
+	}
+}
+
+// WithMaxMessageSize creates a LimitsConfigModifier that sets the maximum size in bytes of the description and meta
+// data together. The largest fields are truncated until the message fits. The size is an approximation measured on
+// the text of the fields before formatting, so the size written by a formatter differs by its own overhead, such as
+// the time, quoting and escaping. The default is 1MB.
+func WithMaxMessageSize(n int) LimitsConfigModifier {
+	return func(c LimitsConfig) LimitsConfig {
+		c.message = n
+		return c
+	}
+}
+
+// Limits truncates messages that are too large before they are formatted. Truncated text ends with a marker such as
+// "…(truncated 38MB)" and the truncated fields are listed under TruncatedKey in the meta data. A limit of zero or less
+// disables that limit. Messages are truncated with the default Limits unless SetLimits or WithLimits say otherwise.
+type Limits struct {
+	c LimitsConfig
+}
+
+// NewLimits creates Limits
+//
+//	SetLimits(NewLimits(WithMaxMessageSize(256 << 10)))
+func NewLimits(configs ...LimitsConfigModifier) *Limits {
+	// default config
+	c := LimitsConfig{
+		desc:    64 << 10,
+		keys:    100,
+		value:   16 << 10,
+		depth:   5,
+		message: 1 << 20,
+	}
+	// apply optional extra config modifiers
+	for _, m := range configs {
+		c = m(c)
+	}
+	return &Limits{c: c}
+}
+
+var (
+	globalLimits  atomic.Value
+	defaultLimits = NewLimits()
+)
+
+// SetLimits sets the Limits applied to messages for every writer that does not have its own. The default is
+// NewLimits() with its default config. Nil Limits disables global truncation.
+func SetLimits(l *Limits) {
+	globalLimits.Store(&l)
+}
+
+// currentLimits returns the Limits set with SetLimits, or the default Limits if it has not been called
+func currentLimits() *Limits {
+	if l, ok := globalLimits.Load().(**Limits); ok {
+		return *l
+	}
+	return defaultLimits
+}
+
+// Apply returns m truncated to the limits. m is returned as it is if it is within the limits, otherwise a copy is
+// returned and m, including its meta data, is left unchanged. Messages within the limits are checked without
+// allocating.
+func (l *Limits) Apply(m *Message) *Message {
+	if l == nil {
+		return m
+	}
+	var truncated []string
+	desc := m.Desc
+	if l.c.desc > 0 && len(desc) > l.c.desc {
+		desc = truncateText(desc, l.c.desc)
+		truncated = append(truncated, "description")
+	}
+	var arr [8]string
+	meta, _ := l.limitMap(m.Meta, append(arr[:0], "metadata"), 1, &truncated)
+	if l.c.message > 0 && !l.fits(desc, meta) {
+		desc, meta = l.limitMessage(desc, meta, &truncated)
+	}
+	if len(truncated) == 0 {
+		return m
+	}
+	c := m.copy()
+	c.Desc = desc
+	c.Meta = make(MetaData, len(meta)+1)
+	for k, v := range meta {
+		c.Meta[k] = v
+	}
+	c.Meta[TruncatedKey] = uniqueStrings(truncated)
+	return c
+}
+
+// limitMap returns a copy of m within the limits, or m itself if it is within them. path holds the keys leading to m,
+// which are only joined when something is truncated.
+func (l *Limits) limitMap(m map[string]any, path []string, depth int, truncated *[]string) (map[string]any, bool) {
+	var out map[string]any
+	if l.c.keys > 0 && len(m) > l.c.keys {
+		all := make([]string, 0, len(m))
+		for k := range m {
+			all = append(all, k)
+		}
+		sort.Strings(all)
+		out = make(map[string]any, l.c.keys)
+		for _, k := range all[:l.c.keys] {
+			out[k] = m[k]
+		}
+		*truncated = append(*truncated, strings.Join(path, "."))
+		m = out
+	}
+	for k, v := range m {
+		nv, changed := l.limitValue(v, append(path, k), depth, truncated)
+		if !changed {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]any, len(m))
+			for ok, ov := range m {
+				out[ok] = ov
+			}
+		}
+		out[k] = nv
+	}
+	if out == nil {
+		return m, false
+	}
+	return out, true
+}
+
+// limitValue returns a copy of v within the limits, or v itself if it is within them
+func (l *Limits) limitValue(v any, path []string, depth int, truncated *[]string) (any, bool) {
+	switch t := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64,
+		time.Duration, time.Time, Type:
+		return v, false
+	case string:
+		if l.c.value > 0 && len(t) > l.c.value {
+			*truncated = append(*truncated, strings.Join(path, "."))
+			return truncateText(t, l.c.value), true
+		}
+		return v, false
+	case Meta, MetaData, map[string]any, []any:
+		if l.c.depth > 0 && depth > l.c.depth {
+			*truncated = append(*truncated, strings.Join(path, "."))
+			return "…(truncated depth)", true
+		}
+		switch t := v.(type) {
+		case Meta:
+			if m, ok := l.limitMap(t, path, depth+1, truncated); ok {
+				return Meta(m), true
+			}
+		case MetaData:
+			if m, ok := l.limitMap(t, path, depth+1, truncated); ok {
+				return MetaData(m), true
+			}
+		case map[string]any:
+			return l.limitMap(t, path, depth+1, truncated)
+		case []any:
+			var out []any
+			for i, e := range t {
+				ne, changed := l.limitValue(e, append(path, strconv.Itoa(i)), depth+1, truncated)
+				if !changed {
+					continue
+				}
+				if out == nil {
+					out = append([]any{}, t...)
+				}
+				out[i] = ne
+			}
+			if out != nil {
+				return out, true
+			}
+		}
+		return v, false
+	}
+	if l.c.value > 0 {
+		if s := appendMetaText(nil, v); len(s) > l.c.value {
+			*truncated = append(*truncated, strings.Join(path, "."))
+			return truncateText(string(s), l.c.value), true
+		}
+	}
+	return v, false
+}
+
+// fits reports whether the description and meta data are certain to be within the maximum message size, using an
+// upper bound of their size that is worked out without rendering the meta data
+func (l *Limits) fits(desc string, meta map[string]any) bool {
+	total := len(desc)
+	for k, v := range meta {
+		total += len(k) + l.sizeBound(v, 0)
+		if total > l.c.message {
+			return false
+		}
+	}
+	return true
+}
+
+// sizeBound returns an upper bound of the size of v rendered as text, or more than the maximum message size if there
+// is none
+func (l *Limits) sizeBound(v any, depth int) int {
+	switch t := v.(type) {
+	case nil, bool:
+		return len("<nil>")
+	case string:
+		return len(t)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, Type:
+		return 20
+	case float32, float64:
+		return 24
+	case time.Duration:
+		return 32
+	case time.Time:
+		return 96
+	case Meta:
+		return l.mapSizeBound(t, depth)
+	case MetaData:
+		return l.mapSizeBound(t, depth)
+	case map[string]any:
+		return l.mapSizeBound(t, depth)
+	case []any:
+		n := 2
+		for _, e := range t {
+			if n += 1 + l.sizeBound(e, depth+1); n > l.c.message {
+				break
+			}
+		}
+		return n
+	}
+	// limitValue has already cut any other value down to the maximum value size
+	if l.c.value > 0 {
+		return l.c.value + maxMarkerSize
+	}
+	return l.c.message + 1
+}
+
+// mapSizeBound returns an upper bound of the size of m rendered as text
+func (l *Limits) mapSizeBound(m map[string]any, depth int) int {
+	if depth > prettyMaxDepth {
+		return l.c.message + 1
+	}
+	n := len("map[]")
+	for k, v := range m {
+		if n += len(k) + 2 + l.sizeBound(v, depth+1); n > l.c.message {
+			break
+		}
+	}
+	return n
+}
+
+// limitField is a top-level field of a message rendered as text by limitMessage
+type limitField struct {
+	key  string
+	text string
+}
+
+// limitMessage truncates the largest of the description and the top-level meta data values until they fit the
+// maximum message size. Every meta data value is rendered once.
+func (l *Limits) limitMessage(desc string, meta map[string]any, truncated *[]string) (string, map[string]any) {
+	fields := make([]limitField, 0, len(meta))
+	total := len(desc)
+	for k, v := range meta {
+		s, ok := v.(string)
+		if !ok {
+			s = string(appendMetaText(nil, v))
+		}
+		fields = append(fields, limitField{key: k, text: s})
+		total += len(k) + len(s)
+	}
+	copied := false
+	for total > l.c.message {
+		// find the largest field that can still shrink
+		i, size := -1, len(desc)
+		for j, f := range fields {
+			if s := len(f.key) + len(f.text); s > size || s == size && i >= 0 && f.key < fields[i].key {
+				i, size = j, s
+			}
+		}
+		if size <= minTruncatedSize+len(truncationMarker(1023)) {
+			break
+		}
+		keep := size - (total - l.c.message)
+		keep -= len(truncationMarker(size - keep))
+		if keep < minTruncatedSize {
+			keep = minTruncatedSize
+		}
+		if i < 0 {
+			d := truncateText(desc, keep)
+			if len(d) >= size {
+				break
+			}
+			desc = d
+			total += len(desc) - size
+			*truncated = append(*truncated, "description")
+			continue
+		}
+		f := &fields[i]
+		s := truncateText(f.text, keep-len(f.key))
+		if len(f.key)+len(s) >= size {
+			break
+		}
+		if !copied {
+			out := make(map[string]any, len(meta))
+			for k, v := range meta {
+				out[k] = v
+			}
+			meta, copied = out, true
+		}
+		meta[f.key] = s
+		f.text = s
+		total += len(f.key) + len(s) - size
+		*truncated = append(*truncated, "metadata."+f.key)
+	}
+	return desc, meta
+}
+
+// uniqueStrings removes repeated strings from s, keeping the first of each
+func uniqueStrings(s []string) []string {
+	out := s[:0]
+	for i, v := range s {
+		seen := false
+		for _, o := range s[:i] {
+			if o == v {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// metaTextSize returns the size of a meta data value rendered as text
+func metaTextSize(v any) int {
+	if s, ok := v.(string); ok {
+		return len(s)
+	}
+	return len(appendMetaText(nil, v))
+}
+
+// truncateText cuts s to at most n bytes, without splitting a rune, and adds a marker with the size removed
+func truncateText(s string, n int) string {
+	if n < 0 {
+		n = 0
+	}
+	if len(s) <= n {
+		return s
+	}
+	cut := n
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + truncationMarker(len(s)-cut)
+}
+
+// truncationMarker returns the marker added to text that had n bytes removed
+func truncationMarker(n int) string {
+	return "…(truncated " + shortBytes(n) + ")"
+}
+
+// shortBytes formats a number of bytes compactly, e.g. 38MB
+func shortBytes(n int) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	i := 0
+	f := float64(n)
+	for f >= 1024 && i < len(units)-1 {
+		f /= 1024
+		i++
+	}
+	return strconv.FormatFloat(f, 'f', 0, 64) + units[i]
+}
@@ -0,0 +1,171 @@
+package logr
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimitsApply(t *testing.T) {
+	l := NewLimits(
+		WithMaxDescLength(10),
+		WithMaxMetaKeys(3),
+		WithMaxValueSize(8),
+		WithMaxDepth(1),
+		WithMaxMessageSize(0),
+	)
+	meta := MetaData{
+		"a": "short",
+		"b": strings.Repeat("x", 20),
+		"c": Meta{"nested": Meta{"deep": 1}},
+		"d": "dropped",
+	}
+	m := &Message{Type: I, Desc: "héllo wonderful world", Meta: meta}
+
+	got := l.Apply(m)
+	if got == m {
+		t.Fatal("expected a truncated copy of the message")
+	}
+	if want := "héllo won…(truncated 12B)"; got.Desc != want {
+		t.Errorf("expected desc %q. Got: %q", want, got.Desc)
+	}
+	want := MetaData{
+		"a":          "short",
+		"b":          "xxxxxxxx…(truncated 12B)",
+		"c":          Meta{"nested": "…(truncated depth)"},
+		TruncatedKey: []string{"description", "metadata", "metadata.b", "metadata.c.nested"},
+	}
+	sorted := got.Meta[TruncatedKey].([]string)
+	// the order of the truncated fields depends on map iteration
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && sorted[j-1] > sorted[j]; j-- {
+			sorted[j-1], sorted[j] = sorted[j], sorted[j-1]
+		}
+	}
+	if !reflect.DeepEqual(got.Meta, want) {
+		t.Errorf("expected meta %v. Got: %v", want, got.Meta)
+	}
+	if len(meta) != 4 || meta["b"] != strings.Repeat("x", 20) {
+		t.Errorf("expected the original meta data to be unchanged. Got: %v", meta)
+	}
+
+	small := &Message{Type: I, Desc: "ok", Meta: MetaData{"a": 1}}
+	if l.Apply(small) != small {
+		t.Error("expected a message within the limits to be returned as it is")
+	}
+}
+
+func TestLimitsMessageSize(t *testing.T) {
+	l := NewLimits(WithMaxMessageSize(1000))
+	m := &Message{
+		Type: I,
+		Desc: strings.Repeat("d", 300),
+		Meta: MetaData{"big": strings.Repeat("b", 5000), "list": []any{strings.Repeat("l", 400)}, "n": 1},
+	}
+	got := l.Apply(m)
+	size := len(got.Desc)
+	for k, v := range got.Meta {
+		if k != TruncatedKey {
+			size += len(k) + metaTextSize(v)
+		}
+	}
+	if size > 1000 {
+		t.Errorf("expected at most 1000 bytes. Got: %d", size)
+	}
+	if got.Desc != m.Desc {
+		t.Errorf("expected the description to be kept. Got: %q", got.Desc)
+	}
+	if s := got.Meta["big"].(string); !strings.HasSuffix(s, "KB)") {
+		t.Errorf("expected the largest value to be truncated. Got: %q", s)
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 2, "he…(truncated 3B)"},
+		{"日本語", 4, "日…(truncated 6B)"},
+		{strings.Repeat("x", 40<<20), 0, "…(truncated 40MB)"},
+	}
+	for _, tt := range tests {
+		if got := truncateText(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncateText(%.10q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestLimitsPerWriter(t *testing.T) {
+	defer SetLimits(currentLimits())
+	SetLimits(NewLimits(WithMaxDescLength(5)))
+
+	text, js, none := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+	for _, stop := range []func(){
+		AddWriter(text),
+		AddWriter(js, WithFormatter(FormatJSON)),
+		AddWriter(none, WithLimits(nil)),
+	} {
+		defer stop()
+	}
+
+	Info("TestLimitsPerWriter")
+	Wait()
+
+	if s := string(mustReadBuffer(text, t)); !strings.Contains(s, "| TestL…(truncated 14B) | map[_truncated:[description] ") {
+		t.Errorf("expected a truncated description. Got: %s", s)
+	}
+	var out struct {
+		Desc string         `json:"description"`
+		Meta map[string]any `json:"metadata"`
+	}
+	if err := json.Unmarshal(mustReadBuffer(js, t), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Desc != "TestL…(truncated 14B)" || !reflect.DeepEqual(out.Meta[TruncatedKey], []any{"description"}) {
+		t.Errorf("expected a truncated description. Got: %+v", out)
+	}
+	if s := string(mustReadBuffer(none, t)); !strings.Contains(s, "| TestLimitsPerWriter | ") {
+		t.Errorf("expected no truncation. Got: %s", s)
+	}
+}
+
+func TestLimitsDefault(t *testing.T) {
+	w := &bytes.Buffer{}
+	defer AddWriter(w, WithFormatter(FormatJSON))()
+
+	Info(strings.Repeat("x", 100<<10))
+	Wait()
+	var out struct {
+		Desc string `json:"description"`
+	}
+	if err := json.Unmarshal(mustReadBuffer(w, t), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(out.Desc, "…(truncated 36KB)") {
+		t.Errorf("expected the description to be truncated by default. Got: %d bytes", len(out.Desc))
+	}
+}
+
+func TestLimitsApplyAllocations(t *testing.T) {
+	l := NewLimits()
+	m := &Message{
+		Type: I,
+		Desc: "request handled",
+		Meta: MetaData{
+			"request_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+			"status":     200,
+			"latency":    15 * time.Millisecond,
+			"user":       Meta{"id": 42, "roles": []any{"admin", "dev"}},
+			"ok":         true,
+		},
+	}
+	if allocs := testing.AllocsPerRun(100, func() { l.Apply(m) }); allocs != 0 {
+		t.Errorf("expected a message within the limits to be checked without allocating. Got: %v allocations", allocs)
+	}
+}
@@ -0,0 +1,174 @@
+package logr
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type LineWriterConfig struct {
+	maxLineLength int
+	meta          Meta
+}
+
+type LineWriterConfigModifier func(c LineWriterConfig) LineWriterConfig
+
+// WithMaxLineLength creates a LineWriterConfigModifier that limits the length of a line. Longer lines are split and
+// logged as multiple messages of at most n bytes. A length of 0 or less disables the limit.
+func WithMaxLineLength(n int) LineWriterConfigModifier {
+	return func(c LineWriterConfig) LineWriterConfig {
+		c.maxLineLength = n
+		return c
+	}
+}
+
+// WithStreamMeta creates a LineWriterConfigModifier that attaches meta data to every message logged by the Writer
+func WithStreamMeta(meta Meta) LineWriterConfigModifier {
+	return func(c LineWriterConfig) LineWriterConfig {
+		c.meta = meta
+		return c
+	}
+}
+
+// lineWriter buffers partial lines and logs every complete line as a Message
+type lineWriter struct {
+	mu     sync.Mutex
+	c      LineWriterConfig
+	t      Type
+	l      Logger
+	buf    []byte
+	closed bool
+}
+
+// Writer returns an io.WriteCloser that logs every line written to it as a Message of the given Type using the given
+// Logger. Partial lines are buffered until they are completed by a newline or the Writer is closed.
+func Writer(l Logger, t Type, configs ...LineWriterConfigModifier) io.WriteCloser {
+	c := LineWriterConfig{}
+	for _, m := range configs {
+		c = m(c)
+	}
+	if c.meta != nil {
+		l = l.With(c.meta)
+	}
+	return &lineWriter{
+		c: c,
+		t: t,
+		l: l,
+	}
+}
+
+// Write buffers p and logs all complete lines
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	buf := append(w.buf, p...)
+	max := w.c.maxLineLength
+	for {
+		i := bytes.IndexByte(buf, '\n')
+		if i >= 0 && (max <= 0 || i <= max) {
+			w.emit(buf[:i])
+			buf = buf[i+1:]
+			continue
+		}
+		if max > 0 && len(buf) >= max {
+			n := cut(buf, max)
+			w.emit(buf[:n])
+			buf = buf[n:]
+			continue
+		}
+		break
+	}
+	w.buf = append(w.buf[:0], buf...)
+
+	return len(p), nil
+}
+
+// cut returns where to split buf so the first part is at most max bytes and no UTF-8 encoded rune is split. A rune
+// that is incomplete at the end of buf is left for the next Write, unless max is too short to hold a single rune.
+func cut(buf []byte, max int) int {
+	n := max
+	if n < len(buf) {
+		for n > 0 && !utf8.RuneStart(buf[n]) {
+			n--
+		}
+	} else {
+		i := n - 1
+		for i > 0 && !utf8.RuneStart(buf[i]) {
+			i--
+		}
+		if !utf8.FullRune(buf[i:n]) {
+			n = i
+		}
+	}
+	if n == 0 {
+		return max
+	}
+	return n
+}
+
+// Close logs any remaining partial line. Writes after Close return io.ErrClosedPipe.
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.emit(w.buf)
+	w.buf = nil
+	w.closed = true
+	return nil
+}
+
+// emit logs a single line, ignoring blank lines
+func (w *lineWriter) emit(line []byte) {
+	s := strings.TrimRight(string(line), "\r")
+	if strings.TrimSpace(s) == "" {
+		return
+	}
+	LogAs(w.l, w.t, s)
+}
+
+// Command runs cmd, logging every line written to stdout as info and every line written to stderr as an error. When
+// the command completes its exit status and duration are logged, as a success if it exited cleanly or as an error
+// otherwise. Writers already set on cmd.Stdout or cmd.Stderr continue to receive the output.
+func Command(cmd *exec.Cmd, l Logger) error {
+	name := cmd.String()
+	stdout := Writer(l, I, WithStreamMeta(Meta{"command": name, "stream": "stdout"}))
+	stderr := Writer(l, E, WithStreamMeta(Meta{"command": name, "stream": "stderr"}))
+	if cmd.Stdout != nil {
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, stdout)
+	} else {
+		cmd.Stdout = stdout
+	}
+	if cmd.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, stderr)
+	} else {
+		cmd.Stderr = stderr
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+	duration := time.Since(start)
+	stdout.Close()
+	stderr.Close()
+
+	code := -1
+	if cmd.ProcessState != nil {
+		code = cmd.ProcessState.ExitCode()
+	}
+	l = l.With(Meta{"command": name, "exit_code": code, "duration": duration})
+	if err != nil {
+		l.Error("command failed:", err)
+		return err
+	}
+	l.Success("command exited")
+	return nil
+}
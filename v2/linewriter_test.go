@@ -0,0 +1,88 @@
+package logr
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	wbuf := &bytes.Buffer{}
+	stop := AddWriter(wbuf)
+	defer stop()
+
+	w := Writer(Default(), W, WithMaxLineLength(10), WithStreamMeta(Meta{"stream": "test"}))
+	w.Write([]byte("partial "))
+	w.Write([]byte("line\n\nline too long to fit\nend"))
+	Wait()
+
+	b := mustReadBuffer(wbuf, t)
+	lines := bytes.Split(bytes.TrimSpace(b), []byte("\n"))
+	want := []string{"| W | partial li |", "| W | ne |", "| W | line too l |", "| W | ong to fit |"}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines. Got: %s", len(want), b)
+	}
+	for i, l := range lines {
+		if !bytes.Contains(l, []byte(want[i])) {
+			t.Errorf("expected line %d to contain '%s'. Got: %s", i, want[i], l)
+		}
+		if !bytes.Contains(l, []byte("stream:test")) {
+			t.Errorf("expected line %d to contain 'stream:test'. Got: %s", i, l)
+		}
+	}
+
+	w.Close()
+	Wait()
+
+	b = mustReadBuffer(wbuf, t)
+	if !bytes.Contains(b, []byte("| W | end |")) {
+		t.Errorf("expected partial line to be logged on close. Got: %s", b)
+	}
+	if _, err := w.Write([]byte("closed\n")); err == nil {
+		t.Error("expected write after close to fail")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	cbuf := &bytes.Buffer{}
+	stop := AddWriter(cbuf)
+	defer stop()
+
+	err = Command(exec.Command(sh, "-c", "echo out; echo err >&2; exit 3"), Default())
+	Wait()
+	errb.Reset()
+
+	if err == nil {
+		t.Error("expected command to fail")
+	}
+	b := mustReadBuffer(cbuf, t)
+	for _, s := range []string{"| I | out |", "stream:stdout", "| E | err |", "stream:stderr", "| E | command failed:", "exit_code:3"} {
+		if !bytes.Contains(b, []byte(s)) {
+			t.Errorf("expected buffer to contain '%s'. Got: %s", s, b)
+		}
+	}
+}
+
+func TestWriterRunes(t *testing.T) {
+	wbuf := &bytes.Buffer{}
+	stop := AddWriter(wbuf)
+	defer stop()
+
+	w := Writer(Default(), I, WithMaxLineLength(6))
+	w.Write([]byte("aaaaé"))
+	w.Write([]byte("\xe2\x82"))
+	w.Write([]byte("\xacbb\naaaaa€b\n"))
+	Wait()
+
+	b := mustReadBuffer(wbuf, t)
+	for _, want := range []string{"| I | aaaaé |", "| I | €bb |", "| I | aaaaa |", "| I | €b |"} {
+		if !bytes.Contains(b, []byte(want)) {
+			t.Errorf("expected '%s' without split runes. Got: %s", want, b)
+		}
+	}
+}
@@ -1,7 +1,50 @@
 package logr
 
-// listen concurrently works through the buffered messages channel
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// maxBufferSize is the largest formatting buffer kept for reuse, so one huge message does not hold on to memory
+const maxBufferSize = 64 << 10
+
+var buffers = &sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+// pipeline is the steps applied to a message before it is formatted: redaction and then truncation
+type pipeline struct {
+	redactor *Redactor
+	limits   *Limits
+}
+
+// apply returns m after the steps of the pipeline
+func (p pipeline) apply(m *Message) *Message {
+	return p.limits.Apply(p.redactor.Redact(m))
+}
+
+// formatted records where the output of a formatter is in the listener buffer
+type formatted struct {
+	key        any
+	pipeline   pipeline
+	start, end int
+}
+
+// prepared records the message produced by a pipeline
+type prepared struct {
+	pipeline pipeline
+	m        *Message
+}
+
+// listen concurrently works through the buffered messages channel. Each message is redacted and truncated once per
+// pipeline and formatted once per formatter, and the output is shared by all the writers using the same pipeline and
+// formatter.
 func listen(ms <-chan *Message) {
+	var done []formatted
+	var ready []prepared
 	for {
 		select {
 
@@ -11,16 +54,47 @@ func listen(ms <-chan *Message) {
 		case wm := <-removeWriter:
 			delete(writers, wm.c)
 
+		case cm := <-control:
+			if cm.fn != nil {
+				cm.done <- cm.fn()
+			} else {
+				cm.done <- flushWriters(cm.close)
+			}
+
 		case m := <-ms:
+			atomic.AddUint64(&stats.messages, 1)
+			buf := buffers.Get().(*[]byte)
+			*buf = (*buf)[:0]
+			done = done[:0]
+			ready = ready[:0]
+			global := pipeline{redactor: currentRedactor(), limits: currentLimits()}
 			for c, w := range writers {
 				if m.Type&c.filter != m.Type {
 					continue
 				}
-				_, err := w.Write(c.format(m))
+				p := c.pipelineOf(global)
+				pm := prepare(p, m, &ready)
+				out, reused := format(c, pm, p, buf, &done)
+				if reused {
+					atomic.AddUint64(&stats.reused, 1)
+				} else {
+					atomic.AddUint64(&stats.formatted, 1)
+				}
+				atomic.AddUint64(&stats.writes, 1)
+				var err error
+				if mw, ok := w.(MessageWriter); ok {
+					_, err = mw.WriteMessage(pm, out)
+				} else {
+					_, err = w.Write(out)
+				}
 				if err != nil {
+					atomic.AddUint64(&stats.errors, 1)
 					Errorf("failed to write message to Writer: %v", err)
 				}
 			}
+			if cap(*buf) <= maxBufferSize {
+				buffers.Put(buf)
+			}
 
 			close(m.done)
 
@@ -30,3 +104,36 @@ func listen(ms <-chan *Message) {
 		}
 	}
 }
+
+// prepare returns m after the pipeline, reusing the message already prepared for another writer with the same pipeline
+func prepare(p pipeline, m *Message, ready *[]prepared) *Message {
+	if p == (pipeline{}) {
+		return m
+	}
+	for _, r := range *ready {
+		if r.pipeline == p {
+			return r.m
+		}
+	}
+	pm := p.apply(m)
+	*ready = append(*ready, prepared{pipeline: p, m: pm})
+	return pm
+}
+
+// format returns the output of the writer's formatter for m, reusing the output already in buf if another writer
+// with the same formatter and pipeline has been written to.
+func format(c *WriterConfig, m *Message, p pipeline, buf *[]byte, done *[]formatted) (out []byte, reused bool) {
+	if c.formatKey != nil {
+		for _, f := range *done {
+			if f.key == c.formatKey && f.pipeline == p {
+				return (*buf)[f.start:f.end], true
+			}
+		}
+	}
+	start := len(*buf)
+	*buf = c.format.AppendFormat(*buf, m)
+	if c.formatKey != nil {
+		*done = append(*done, formatted{key: c.formatKey, pipeline: p, start: start, end: len(*buf)})
+	}
+	return (*buf)[start:], false
+}
@@ -0,0 +1,70 @@
+package logr
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestListenFormatsOncePerFormatter(t *testing.T) {
+	tf := MustTemplateFormatter("{{.Desc}}")
+	RegisterFormatter("TestListenFormatsOncePerFormatter", tf)
+	jf := NewJSONFormatter(WithJSONFieldName(JSONFieldCode, ""))
+
+	bufs := make([]*bytes.Buffer, 6)
+	for i := range bufs {
+		bufs[i] = &bytes.Buffer{}
+	}
+	for _, stop := range []func(){
+		AddWriter(bufs[0], WithFormatter(FormatJSON)),
+		AddWriter(bufs[1], WithFormatter(FormatJSON)),
+		AddWriter(bufs[2], WithAppendFormatter(jf)),
+		AddWriter(bufs[3], WithAppendFormatter(jf)),
+		AddWriter(bufs[4], WithNamedFormatter("TestListenFormatsOncePerFormatter")),
+		AddWriter(bufs[5], WithNamedFormatter("TestListenFormatsOncePerFormatter")),
+	} {
+		defer stop()
+	}
+
+	before := Stats()
+	Info("TestListenFormatsOncePerFormatter message")
+	Wait()
+	after := Stats()
+
+	// the TestMain json writer shares its output with the first two writers
+	if got := after.Reused - before.Reused; got != 4 {
+		t.Errorf("expected 4 reused writes. Got: %d", got)
+	}
+	if got := after.Messages - before.Messages; got != 1 {
+		t.Errorf("expected 1 message. Got: %d", got)
+	}
+	for i := 0; i < len(bufs); i += 2 {
+		a, b := bufs[i].String(), bufs[i+1].String()
+		if a != b || a == "" {
+			t.Errorf("expected writers %d and %d to receive the same output. Got: %q and %q", i, i+1, a, b)
+		}
+	}
+	if bytes.Contains(bufs[2].Bytes(), []byte(`"code"`)) {
+		t.Errorf("expected the configured json formatter to be used. Got: %s", bufs[2])
+	}
+}
+
+func TestFormatterKey(t *testing.T) {
+	jf := NewJSONFormatter()
+	tests := []struct {
+		name   string
+		f      any
+		shared bool
+	}{
+		{name: "Built-in function", f: Formatter(FormatJSON), shared: true},
+		{name: "Pointer", f: jf, shared: true},
+		{name: "Closure", f: MustTemplateFormatter("{{.Desc}}"), shared: false},
+		{name: "Method value", f: Formatter(jf.Format), shared: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatterKey(tt.f) != nil; got != tt.shared {
+				t.Errorf("formatterKey() shared = %v, want %v", got, tt.shared)
+			}
+		})
+	}
+}
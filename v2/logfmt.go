@@ -0,0 +1,194 @@
+package logr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FormatLogfmt is a Formatter that converts a Message to logfmt, for example:
+//
+//	time=2006-01-02T15:04:05.999999999Z07:00 level=info code=k5f9x3 msg="some message" user.id=42
+//
+// Meta data is written in key order after the message, with nested maps flattened into dotted keys. Meta data keys
+// that would be mistaken for the time, level, code or msg keys, or that start with "meta.", are prefixed with "meta.".
+func FormatLogfmt(m *Message) []byte {
+	b := make([]byte, 0, 128)
+	if m.Timestamp.IsZero() {
+		b = appendLogfmtPair(b, "time", m.Time)
+	} else {
+		b = appendLogfmtPair(b, "time", m.Timestamp.Format(time.RFC3339Nano))
+	}
+	b = append(b, ' ')
+	b = appendLogfmtPair(b, "level", m.Type.String())
+	b = append(b, ' ')
+	b = appendLogfmtPair(b, "code", m.Code)
+	b = append(b, ' ')
+	b = appendLogfmtPair(b, "msg", m.Desc)
+	flatten(m.Meta, func(key string, value any) {
+		b = append(b, ' ')
+		if isLogfmtReserved(key) {
+			key = logfmtMetaPrefix + key
+		}
+		b = appendLogfmtPair(b, key, logfmtValue(value))
+	})
+	return append(b, '\n')
+}
+
+// logfmtMetaPrefix is added to meta data keys that would collide with the keys FormatLogfmt writes itself
+const logfmtMetaPrefix = "meta."
+
+// isLogfmtReserved reports whether a meta data key must be prefixed so ParseLogfmt can tell it apart
+func isLogfmtReserved(key string) bool {
+	switch key {
+	case "time", "level", "code", "msg":
+		return true
+	}
+	return strings.HasPrefix(key, logfmtMetaPrefix)
+}
+
+// appendLogfmtPair appends key=value to b, quoting the value if required
+func appendLogfmtPair(b []byte, key, value string) []byte {
+	b = appendLogfmtKey(b, key)
+	b = append(b, '=')
+	if logfmtNeedsQuotes(value) {
+		return strconv.AppendQuote(b, value)
+	}
+	return append(b, value...)
+}
+
+// appendLogfmtKey appends key to b, replacing the characters that are not allowed in a key with underscores
+func appendLogfmtKey(b []byte, key string) []byte {
+	if key == "" {
+		return append(b, '_')
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || unicode.IsControl(r) {
+			r = '_'
+		}
+		b = utf8.AppendRune(b, r)
+	}
+	return b
+}
+
+// logfmtNeedsQuotes reports whether a value must be quoted to be parsed back
+func logfmtNeedsQuotes(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || unicode.IsControl(r) || unicode.IsSpace(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// logfmtValue converts a meta data value to a string
+func logfmtValue(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case error:
+		return errorText(t)
+	// time.Time and time.Duration implement fmt.Stringer, so they come first
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case time.Duration:
+		return t.String()
+	case fmt.Stringer:
+		return stringerText(t)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ParseLogfmt parses a line written by FormatLogfmt back into a Message. The time, level, code and msg keys are set on
+// the corresponding Message fields and every other key is added to the Message meta data as a string, with the "meta."
+// prefix FormatLogfmt adds to colliding keys removed. Nested keys are kept in their dotted form.
+func ParseLogfmt(line []byte) (*Message, error) {
+	m := &Message{}
+	s := string(bytes.TrimSpace(line))
+	for s != "" {
+		var key, value string
+		var err error
+		key, value, s, err = nextLogfmtPair(s)
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "time":
+			if ts, err := time.Parse(time.RFC3339Nano, value); err == nil {
+				m.Timestamp = ts
+				m.Time = ts.Format(TimeFormat)
+			} else {
+				m.Time = value
+			}
+		case "level":
+			t, ok := nameToType(value)
+			if !ok {
+				return nil, fmt.Errorf("logr: unknown level %q in logfmt line", value)
+			}
+			m.Type = t
+		case "code":
+			m.Code = value
+		case "msg":
+			m.Desc = value
+		default:
+			if m.Meta == nil {
+				m.Meta = MetaData{}
+			}
+			m.Meta[strings.TrimPrefix(key, logfmtMetaPrefix)] = value
+		}
+	}
+	return m, nil
+}
+
+// nextLogfmtPair reads the next key=value pair from s and returns the remainder of s
+func nextLogfmtPair(s string) (key, value, rest string, err error) {
+	s = strings.TrimLeft(s, " ")
+	end := strings.IndexAny(s, "= ")
+	if end == -1 {
+		return s, "", "", nil
+	}
+	key = s[:end]
+	if key == "" {
+		return "", "", "", errors.New("logr: missing key in logfmt line")
+	}
+	if s[end] == ' ' {
+		return key, "", s[end:], nil
+	}
+	s = s[end+1:]
+
+	if !strings.HasPrefix(s, `"`) {
+		end = strings.IndexByte(s, ' ')
+		if end == -1 {
+			return key, s, "", nil
+		}
+		return key, s[:end], s[end:], nil
+	}
+
+	// find the closing quote, skipping escaped characters
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			value, err = strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", "", fmt.Errorf("logr: invalid quoted value for %q in logfmt line: %w", key, err)
+			}
+			return key, value, s[i+1:], nil
+		}
+	}
+	return "", "", "", fmt.Errorf("logr: unterminated quoted value for %q in logfmt line", key)
+}
@@ -0,0 +1,102 @@
+package logr
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFormatLogfmt(t *testing.T) {
+	ts := time.Date(2026, 10, 17, 14, 5, 6, 7000000, time.UTC)
+	m := &Message{
+		Type:      W,
+		Time:      ts.Format(TimeFormat),
+		Code:      "abc123",
+		Desc:      "disk \"data\" is\nfull",
+		Timestamp: ts,
+		Meta: MetaData{
+			"user":  Meta{"id": 42, "name": "Jane Doe"},
+			"error": errors.New("no space"),
+			"empty": "",
+			"a=b":   true,
+			"at":    time.Date(2026, 10, 17, 16, 5, 6, 0, time.FixedZone("CEST", 2*3600)),
+			"took":  1500 * time.Millisecond,
+		},
+	}
+	want := `time=2026-10-17T14:05:06.007Z level=warning code=abc123 msg="disk \"data\" is\nfull" a_b=true at=2026-10-17T16:05:06+02:00 empty="" error="no space" took=1.5s user.id=42 user.name="Jane Doe"` + "\n"
+	if got := string(FormatLogfmt(m)); got != want {
+		t.Errorf("FormatLogfmt() = %s, want %s", got, want)
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	ts := time.Date(2026, 10, 17, 14, 5, 6, 7000000, time.UTC)
+	m := &Message{
+		Type:      E,
+		Time:      ts.Format(TimeFormat),
+		Code:      "abc123",
+		Desc:      "failed = \\ \"badly\"",
+		Timestamp: ts,
+		Meta:      MetaData{"user": Meta{"id": 42}, "path": "/a b"},
+	}
+	got, err := ParseLogfmt(FormatLogfmt(m))
+	if err != nil {
+		t.Fatalf("ParseLogfmt() error = %v", err)
+	}
+	want := &Message{
+		Type:      E,
+		Time:      m.Time,
+		Code:      "abc123",
+		Desc:      m.Desc,
+		Timestamp: ts,
+		Meta:      MetaData{"user.id": "42", "path": "/a b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseLogfmt() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLogfmtReservedKeys(t *testing.T) {
+	cyclic := Meta{}
+	cyclic["self"] = cyclic
+	m := &Message{
+		Type: I,
+		Code: "abc123",
+		Desc: "message",
+		Meta: MetaData{"level": "debug", "msg": "meta msg", "meta": Meta{"x": 1}, "cyclic": cyclic},
+	}
+	b := FormatLogfmt(m)
+	want := `level=info code=abc123 msg=message cyclic.self=<cycle> meta.level=debug meta.meta.x=1 meta.msg="meta msg"`
+	if !bytes.Contains(b, []byte(want)) {
+		t.Errorf("FormatLogfmt() = %s, want %s", b, want)
+	}
+
+	got, err := ParseLogfmt(b)
+	if err != nil {
+		t.Fatalf("ParseLogfmt() error = %v", err)
+	}
+	meta := MetaData{"level": "debug", "msg": "meta msg", "meta.x": "1", "cyclic.self": "<cycle>"}
+	if got.Type != I || got.Desc != "message" || !reflect.DeepEqual(got.Meta, meta) {
+		t.Errorf("ParseLogfmt() = %+v, want type info, msg message and meta %v", got, meta)
+	}
+}
+
+func TestParseLogfmtErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "Unknown level", line: `level=loud msg=hi`},
+		{name: "Unterminated quote", line: `level=info msg="hi`},
+		{name: "Missing key", line: `level=info =hi`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseLogfmt([]byte(tt.line)); err == nil {
+				t.Errorf("ParseLogfmt(%s) expected an error", tt.line)
+			}
+		})
+	}
+}
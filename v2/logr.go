@@ -1,6 +1,7 @@
 package logr
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"strconv"
@@ -8,6 +9,9 @@ import (
 	"time"
 )
 
+// TimeFormat is the layout of Message.Time
+const TimeFormat = "Jan 02 2006 15:04:05.9999"
+
 var (
 	messages chan *Message
 
@@ -59,71 +63,130 @@ type Logger interface {
 	With(data Meta) Logger
 }
 
-// Logr implements the Logger interface
+// TypeLogger is implemented by Loggers that log messages of a Type chosen at run time, such as Logr. It is separate
+// from Logger so existing Logger implementations keep satisfying it.
+type TypeLogger interface {
+	Log(t Type, v ...any) string
+	Logf(t Type, msg string, v ...any) string
+}
+
+// ContextLogger is implemented by Loggers that add the meta data found in a context to the log messages, such as Logr.
+// It is separate from Logger so existing Logger implementations keep satisfying it.
+type ContextLogger interface {
+	Ctx(ctx context.Context) Logger
+	PanicCtx(ctx context.Context, v ...any)
+	ErrorCtx(ctx context.Context, v ...any) string
+	WarnCtx(ctx context.Context, v ...any) string
+	InfoCtx(ctx context.Context, v ...any) string
+	DebugCtx(ctx context.Context, v ...any) string
+	SuccessCtx(ctx context.Context, v ...any) string
+}
+
+// WithCtx returns a Logger that adds the meta data found in ctx to the log messages logged with l. Loggers that are not
+// ContextLoggers get the meta data through With, with the registered context extractors run once.
+func WithCtx(l Logger, ctx context.Context) Logger {
+	if cl, ok := l.(ContextLogger); ok {
+		return cl.Ctx(ctx)
+	}
+	return l.With(extract(ctx, nil))
+}
+
+// LogAs logs inputs as the given type with l. Loggers that are not TypeLoggers log them with the method for the type,
+// except that P messages are logged as errors rather than panicking.
+func LogAs(l Logger, t Type, v ...any) string {
+	if tl, ok := l.(TypeLogger); ok {
+		return tl.Log(t, v...)
+	}
+	switch t {
+	case P, E:
+		return l.Error(v...)
+	case W:
+		return l.Warn(v...)
+	case D:
+		return l.Debug(v...)
+	case S:
+		return l.Success(v...)
+	default:
+		return l.Info(v...)
+	}
+}
+
+// Logr implements the Logger, TypeLogger and ContextLogger interfaces
 type Logr struct {
 	meta Meta
+	ctx  context.Context
 }
 
 // Panic logs inputs as panics and panics
 func (l *Logr) Panic(v ...any) {
-	code := log(P, true, Interfaces(v).SSV(), l.meta)
+	code := log(P, true, Interfaces(v).SSV(), l.data())
 	panic(code)
 }
 
 // Panicf logs a formatted message as a panic and panics
 func (l *Logr) Panicf(msg string, v ...any) {
-	code := logf(P, true, msg, v, l.meta)
+	code := logf(P, true, msg, v, l.data())
 	panic(code)
 }
 
 // Error logs inputs as errors
 func (l *Logr) Error(v ...any) string {
-	return log(E, false, Interfaces(v).SSV(), l.meta)
+	return log(E, false, Interfaces(v).SSV(), l.data())
 }
 
 // Errorf logs a formatted message as an error
 func (l *Logr) Errorf(msg string, v ...any) string {
-	return logf(E, false, msg, v, l.meta)
+	return logf(E, false, msg, v, l.data())
 }
 
 // Warn logs inputs as warnings
 func (l *Logr) Warn(v ...any) string {
-	return log(W, false, Interfaces(v).SSV(), l.meta)
+	return log(W, false, Interfaces(v).SSV(), l.data())
 }
 
 // Warnf logs a formatted message as a warning
 func (l *Logr) Warnf(msg string, v ...any) string {
-	return logf(W, false, msg, v, l.meta)
+	return logf(W, false, msg, v, l.data())
 }
 
 // Info logs inputs as info messages
 func (l *Logr) Info(v ...any) string {
-	return log(I, false, Interfaces(v).SSV(), l.meta)
+	return log(I, false, Interfaces(v).SSV(), l.data())
 }
 
 // Infof logs a formatted message as an info message
 func (l *Logr) Infof(msg string, v ...any) string {
-	return logf(I, false, msg, v, l.meta)
+	return logf(I, false, msg, v, l.data())
 }
 
 // Debug logs inputs as debug messages
 func (l *Logr) Debug(v ...any) string {
-	return log(D, false, Interfaces(v).SSV(), l.meta)
+	return log(D, false, Interfaces(v).SSV(), l.data())
 }
 
 // Debugf logs a formatted message as a debug message
 func (l *Logr) Debugf(msg string, v ...any) string {
-	return logf(D, false, msg, v, l.meta)
+	return logf(D, false, msg, v, l.data())
 }
 
 // Success logs inputs as success messages
 func (l *Logr) Success(v ...any) string {
-	return log(S, false, Interfaces(v).SSV(), l.meta)
+	return log(S, false, Interfaces(v).SSV(), l.data())
 }
 
 // Successf logs a formatted message as a success message
 func (l *Logr) Successf(msg string, v ...any) string {
-	return logf(S, false, msg, v, l.meta)
+	return logf(S, false, msg, v, l.data())
+}
+
+// Log logs inputs as the given type. Unlike Panic, logging with P waits for the message to be written but does not panic.
+func (l *Logr) Log(t Type, v ...any) string {
+	return log(t, t == P, Interfaces(v).SSV(), l.data())
+}
+
+// Logf logs a formatted message as the given type
+func (l *Logr) Logf(t Type, msg string, v ...any) string {
+	return logf(t, t == P, msg, v, l.data())
 }
 
 // With metadata in the log messages
@@ -136,7 +199,55 @@ func (l *Logr) With(data Meta) Logger {
 	}
 	return &Logr{
 		meta: meta,
+		ctx:  l.ctx,
+	}
+}
+
+// Ctx returns a Logger that adds the meta data found in ctx to the log messages. The registered context extractors
+// are run against ctx every time a message is logged.
+func (l *Logr) Ctx(ctx context.Context) Logger {
+	return &Logr{
+		meta: l.meta,
+		ctx:  ctx,
+	}
+}
+
+// PanicCtx logs inputs as panics with the meta data found in ctx and panics
+func (l *Logr) PanicCtx(ctx context.Context, v ...any) {
+	l.Ctx(ctx).Panic(v...)
+}
+
+// ErrorCtx logs inputs as errors with the meta data found in ctx
+func (l *Logr) ErrorCtx(ctx context.Context, v ...any) string {
+	return l.Ctx(ctx).Error(v...)
+}
+
+// WarnCtx logs inputs as warnings with the meta data found in ctx
+func (l *Logr) WarnCtx(ctx context.Context, v ...any) string {
+	return l.Ctx(ctx).Warn(v...)
+}
+
+// InfoCtx logs inputs as info messages with the meta data found in ctx
+func (l *Logr) InfoCtx(ctx context.Context, v ...any) string {
+	return l.Ctx(ctx).Info(v...)
+}
+
+// DebugCtx logs inputs as debug messages with the meta data found in ctx
+func (l *Logr) DebugCtx(ctx context.Context, v ...any) string {
+	return l.Ctx(ctx).Debug(v...)
+}
+
+// SuccessCtx logs inputs as success messages with the meta data found in ctx
+func (l *Logr) SuccessCtx(ctx context.Context, v ...any) string {
+	return l.Ctx(ctx).Success(v...)
+}
+
+// data returns the meta data for a log message, including anything extracted from the Logger's context
+func (l *Logr) data() Meta {
+	if l.ctx == nil {
+		return l.meta
 	}
+	return extract(l.ctx, l.meta)
 }
 
 // format a msg and log as given type
@@ -149,18 +260,21 @@ func log(t Type, wait bool, msg string, meta map[string]any) string {
 	now := time.Now()
 	m := pool.Get().(*Message)
 	m.Type = t
-	m.Time = now.Format("Jan 02 2006 15:04:05.9999")
+	m.Time = now.Format(TimeFormat)
+	m.Timestamp = now
 	m.Code = strconv.FormatInt(now.UnixNano(), 36)
 	m.Desc = msg
 	m.Meta = meta
 	m.done = make(chan struct{})
+	// m is reset and reused once the listener is done with it, so nothing may be read from it after it is sent
+	code, done := m.Code, m.done
 	messages <- m
 
 	if wait {
-		<-m.done
+		<-done
 	}
 
-	return m.Code
+	return code
 }
 
 // Panic logs inputs as panics and panics
@@ -223,11 +337,59 @@ func Successf(msg string, v ...any) string {
 	return logr.Successf(msg, v...)
 }
 
+// Log logs inputs as the given type
+func Log(t Type, v ...any) string {
+	return LogAs(logr, t, v...)
+}
+
+// Logf logs a formatted message as the given type
+func Logf(t Type, msg string, v ...any) string {
+	if tl, ok := logr.(TypeLogger); ok {
+		return tl.Logf(t, msg, v...)
+	}
+	return LogAs(logr, t, fmt.Sprintf(msg, v...))
+}
+
 // With metadata in the log messages
 func With(data Meta) Logger {
 	return logr.With(data)
 }
 
+// Ctx returns a Logger that adds the meta data found in ctx to the log messages
+func Ctx(ctx context.Context) Logger {
+	return WithCtx(logr, ctx)
+}
+
+// PanicCtx logs inputs as panics with the meta data found in ctx and panics
+func PanicCtx(ctx context.Context, v ...any) {
+	Ctx(ctx).Panic(v...)
+}
+
+// ErrorCtx logs inputs as errors with the meta data found in ctx
+func ErrorCtx(ctx context.Context, v ...any) string {
+	return Ctx(ctx).Error(v...)
+}
+
+// WarnCtx logs inputs as warnings with the meta data found in ctx
+func WarnCtx(ctx context.Context, v ...any) string {
+	return Ctx(ctx).Warn(v...)
+}
+
+// InfoCtx logs inputs as info messages with the meta data found in ctx
+func InfoCtx(ctx context.Context, v ...any) string {
+	return Ctx(ctx).Info(v...)
+}
+
+// DebugCtx logs inputs as debug messages with the meta data found in ctx
+func DebugCtx(ctx context.Context, v ...any) string {
+	return Ctx(ctx).Debug(v...)
+}
+
+// SuccessCtx logs inputs as success messages with the meta data found in ctx
+func SuccessCtx(ctx context.Context, v ...any) string {
+	return Ctx(ctx).Success(v...)
+}
+
 // Default gets the default logger
 func Default() Logger {
 	return logr
@@ -208,3 +208,47 @@ func ExampleLabelToType() {
 	// which is equivalent to
 	// 		AddWriter(os.Stdout, WithFilter(P | E | W | I | S)) // debug logs are filtered out
 }
+
+// methodLogger implements only the Logger methods, like Loggers written outside this package
+type methodLogger struct {
+	calls []string
+	meta  Meta
+}
+
+func (l *methodLogger) call(name string, v []any) string {
+	l.calls = append(l.calls, name+" "+fmt.Sprint(v...))
+	return name
+}
+func (l *methodLogger) Panic(v ...any)                       { l.call("Panic", v); panic(v) }
+func (l *methodLogger) Panicf(msg string, v ...any)          { l.call("Panicf", v); panic(msg) }
+func (l *methodLogger) Error(v ...any) string                { return l.call("Error", v) }
+func (l *methodLogger) Errorf(msg string, v ...any) string   { return l.call("Errorf", v) }
+func (l *methodLogger) Warn(v ...any) string                 { return l.call("Warn", v) }
+func (l *methodLogger) Warnf(msg string, v ...any) string    { return l.call("Warnf", v) }
+func (l *methodLogger) Info(v ...any) string                 { return l.call("Info", v) }
+func (l *methodLogger) Infof(msg string, v ...any) string    { return l.call("Infof", v) }
+func (l *methodLogger) Debug(v ...any) string                { return l.call("Debug", v) }
+func (l *methodLogger) Debugf(msg string, v ...any) string   { return l.call("Debugf", v) }
+func (l *methodLogger) Success(v ...any) string              { return l.call("Success", v) }
+func (l *methodLogger) Successf(msg string, v ...any) string { return l.call("Successf", v) }
+func (l *methodLogger) With(data Meta) Logger                { l.meta = data; return l }
+
+func TestLogAs(t *testing.T) {
+	l := &methodLogger{}
+	for _, typ := range []Type{P, E, W, I, D, S} {
+		LogAs(l, typ, "x")
+	}
+	want := "Error x,Error x,Warn x,Info x,Debug x,Success x"
+	if got := strings.Join(l.calls, ","); got != want {
+		t.Errorf("expected the methods for each Type. Got: %s", got)
+	}
+
+	var _ TypeLogger = &Logr{}
+	rbuf := &bytes.Buffer{}
+	defer AddWriter(rbuf, WithFilter(W))()
+	LogAs(Default(), W, "TestLogAs")
+	Wait()
+	if s := string(mustReadBuffer(rbuf, t)); !strings.Contains(s, "| W | TestLogAs") {
+		t.Errorf("expected a Logr to log the Type itself. Got: %s", s)
+	}
+}
@@ -0,0 +1,306 @@
+// Package logrhttp provides net/http middleware that logs requests with logr.
+package logrhttp
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/raisemarketplace/logr/v2"
+)
+
+// Redacted replaces the value of captured headers that are configured to be redacted
+const Redacted = "[REDACTED]"
+
+// maxRequestIDLength is the longest request ID accepted from a request header
+const maxRequestIDLength = 128
+
+type Config struct {
+	requestIDHeader string
+	skipPaths       []string
+	headers         []string
+	redact          map[string]bool
+	successType     logr.Type
+}
+
+type ConfigModifier func(c Config) Config
+
+// WithRequestIDHeader creates a ConfigModifier that sets the header used to propagate the request ID. The default is
+// X-Request-Id.
+func WithRequestIDHeader(name string) ConfigModifier {
+	return func(c Config) Config {
+		c.requestIDHeader = http.CanonicalHeaderKey(name)
+		return c
+	}
+}
+
+// WithSkipPaths creates a ConfigModifier that stops requests to the given paths being logged. A path ending in *
+// matches every path starting with what comes before it. Skipped requests still get a request ID and Logger.
+func WithSkipPaths(paths ...string) ConfigModifier {
+	return func(c Config) Config {
+		c.skipPaths = append(c.skipPaths, paths...)
+		return c
+	}
+}
+
+// WithHeaders creates a ConfigModifier that adds the values of the given request headers to the log message
+func WithHeaders(names ...string) ConfigModifier {
+	return func(c Config) Config {
+		for _, n := range names {
+			c.headers = append(c.headers, http.CanonicalHeaderKey(n))
+		}
+		return c
+	}
+}
+
+// WithRedactedHeaders creates a ConfigModifier that replaces the values of the given headers with Redacted when they
+// are captured. Authorization, Proxy-Authorization and Cookie are always redacted.
+func WithRedactedHeaders(names ...string) ConfigModifier {
+	return func(c Config) Config {
+		redact := make(map[string]bool, len(c.redact)+len(names))
+		for n := range c.redact {
+			redact[n] = true
+		}
+		for _, n := range names {
+			redact[http.CanonicalHeaderKey(n)] = true
+		}
+		c.redact = redact
+		return c
+	}
+}
+
+// WithSuccessType creates a ConfigModifier that sets the Type used to log 2xx responses. The default is logr.S.
+func WithSuccessType(t logr.Type) ConfigModifier {
+	return func(c Config) Config {
+		c.successType = t
+		return c
+	}
+}
+
+// Middleware returns net/http middleware that logs every request once it has been handled. A request ID is taken from
+// the request ID header or generated, and returned in the response. Request IDs longer than 128 characters or
+// containing anything other than letters, digits and -_.:+/= are replaced with a generated one. A Logger carrying the request ID is added to the
+// request context, where logr.FromContext can find it, together with the request ID as context meta data and any W3C
+// trace context headers.
+//
+// The method, path, status, bytes written, latency and remote address are logged as meta data. The Type of the
+// message depends on the response status: 5xx is logged as an error, 4xx as a warning, 2xx as a success and anything
+// else as info.
+func Middleware(l logr.Logger, configs ...ConfigModifier) func(http.Handler) http.Handler {
+	// default config
+	c := Config{
+		requestIDHeader: "X-Request-Id",
+		redact: map[string]bool{
+			"Authorization":       true,
+			"Proxy-Authorization": true,
+			"Cookie":              true,
+		},
+		successType: logr.S,
+	}
+	// apply optional extra config modifiers
+	for _, m := range configs {
+		c = m(c)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := r.Header.Get(c.requestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+				r.Header.Set(c.requestIDHeader, id)
+			}
+			w.Header().Set(c.requestIDHeader, id)
+
+			rl := l.With(logr.Meta{"request_id": id})
+			ctx := logr.ContextWithMeta(r.Context(), logr.Meta{"request_id": id})
+			ctx = logr.ContextWithLogger(ctx, rl)
+			if tp := r.Header.Get("Traceparent"); tp != "" {
+				ctx = logr.ContextWithTraceContext(ctx, tp, r.Header.Get("Tracestate"))
+			}
+			r = r.WithContext(ctx)
+
+			rw := &responseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			if c.skipped(r.URL.Path) {
+				return
+			}
+			status := rw.Status()
+			meta := logr.Meta{
+				"method":      r.Method,
+				"path":        r.URL.Path,
+				"status":      status,
+				"bytes":       rw.bytes,
+				"latency":     time.Since(start),
+				"remote_addr": r.RemoteAddr,
+			}
+			if h := c.capture(r.Header); h != nil {
+				meta.With("headers", h)
+			}
+			logr.LogAs(logr.WithCtx(rl.With(meta), ctx), c.typeOf(status), r.Method, r.URL.Path, status)
+		})
+	}
+}
+
+// skipped reports whether requests to path should not be logged
+func (c Config) skipped(path string) bool {
+	for _, p := range c.skipPaths {
+		if strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(path, strings.TrimSuffix(p, "*")) {
+				return true
+			}
+		} else if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// capture returns the configured headers found in h, with redacted values replaced
+func (c Config) capture(h http.Header) logr.Meta {
+	var meta logr.Meta
+	for _, n := range c.headers {
+		v, ok := h[n]
+		if !ok {
+			continue
+		}
+		if meta == nil {
+			meta = logr.Meta{}
+		}
+		if c.redact[n] {
+			meta.With(n, Redacted)
+		} else {
+			meta.With(n, strings.Join(v, ", "))
+		}
+	}
+	return meta
+}
+
+// typeOf returns the Type a response with the given status is logged as
+func (c Config) typeOf(status int) logr.Type {
+	switch {
+	case status >= 500:
+		return logr.E
+	case status >= 400:
+		return logr.W
+	case status >= 200 && status < 300:
+		return c.successType
+	default:
+		return logr.I
+	}
+}
+
+// validRequestID reports whether id is a non-empty request ID that is safe to log and return in a header
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && !strings.ContainsRune("-_.:+/=", rune(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random 128 bit request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strings.ReplaceAll(time.Now().UTC().Format("20060102150405.000000000"), ".", "")
+	}
+	return hex.EncodeToString(b)
+}
+
+// responseWriter records the status and number of bytes written in a response
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// WriteHeader records the status before writing it. Informational statuses other than 101 Switching Protocols, such
+// as 103 Early Hints, are followed by the final status, so they are not recorded.
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 && (status >= 200 || status == http.StatusSwitchingProtocols) {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the number of bytes written
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Flush implements http.Flusher if the underlying http.ResponseWriter does
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the underlying http.ResponseWriter does. A hijacked connection is recorded as
+// 101 Switching Protocols unless a status was already written.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// ReadFrom implements io.ReaderFrom so the underlying http.ResponseWriter can still use sendfile, recording the number
+// of bytes written
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(writerOnly{w.ResponseWriter}, r)
+	}
+	w.bytes += int(n)
+	return n, err
+}
+
+// writerOnly hides any io.ReaderFrom implementation so io.Copy does not call back into ReadFrom
+type writerOnly struct {
+	io.Writer
+}
+
+// Unwrap returns the underlying http.ResponseWriter for http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status returns the status written, defaulting to 200 if nothing has been written
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
@@ -0,0 +1,162 @@
+package logrhttp
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raisemarketplace/logr/v2"
+)
+
+func serve(h http.Handler, r *http.Request) (*httptest.ResponseRecorder, []byte) {
+	buf := &bytes.Buffer{}
+	stop := logr.AddWriter(buf, logr.WithFormatter(logr.FormatJSON))
+	defer stop()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	logr.Wait()
+	return w, buf.Bytes()
+}
+
+func TestMiddleware(t *testing.T) {
+	var fromContext logr.Logger
+	h := Middleware(logr.Default(), WithHeaders("User-Agent", "Authorization"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fromContext = logr.FromContext(r.Context())
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/things/1", nil)
+	r.Header.Set("X-Request-Id", "req-1")
+	r.Header.Set("User-Agent", "test")
+	r.Header.Set("Authorization", "Bearer secret")
+	w, b := serve(h, r)
+
+	if got := w.Header().Get("X-Request-Id"); got != "req-1" {
+		t.Errorf("expected request ID to be propagated. Got: %s", got)
+	}
+	if fromContext == logr.Default() {
+		t.Error("expected a request scoped logger in the context")
+	}
+	for _, s := range []string{`"type":"warning"`, `"request_id":"req-1"`, `"method":"GET"`, `"path":"/things/1"`, `"status":404`, `"bytes":9`, `"User-Agent":"test"`, `"Authorization":"[REDACTED]"`} {
+		if !bytes.Contains(b, []byte(s)) {
+			t.Errorf("expected log to contain '%s'. Got: %s", s, b)
+		}
+	}
+	if bytes.Contains(b, []byte("secret")) {
+		t.Errorf("expected authorization header to be redacted. Got: %s", b)
+	}
+}
+
+func TestMiddlewareGeneratesRequestID(t *testing.T) {
+	h := Middleware(logr.Default())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	w, b := serve(h, httptest.NewRequest(http.MethodPost, "/", nil))
+	if len(w.Header().Get("X-Request-Id")) != 32 {
+		t.Errorf("expected a generated request ID. Got: %s", w.Header().Get("X-Request-Id"))
+	}
+	if !bytes.Contains(b, []byte(`"type":"success"`)) {
+		t.Errorf("expected a success message. Got: %s", b)
+	}
+}
+
+func TestMiddlewareReplacesInvalidRequestID(t *testing.T) {
+	h := Middleware(logr.Default())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, id := range []string{"id with spaces", "id\x1b[31m", strings.Repeat("a", 129)} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Request-Id", id)
+		if w, _ := serve(h, r); len(w.Header().Get("X-Request-Id")) != 32 {
+			t.Errorf("expected %q to be replaced with a generated request ID. Got: %s", id, w.Header().Get("X-Request-Id"))
+		}
+	}
+}
+
+func TestMiddlewareSkipPaths(t *testing.T) {
+	h := Middleware(logr.Default(), WithSkipPaths("/health", "/metrics/*"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	for _, p := range []string{"/health", "/metrics/cpu"} {
+		if _, b := serve(h, httptest.NewRequest(http.MethodGet, p, nil)); len(b) > 0 {
+			t.Errorf("expected %s not to be logged. Got: %s", p, b)
+		}
+	}
+	if _, b := serve(h, httptest.NewRequest(http.MethodGet, "/healthz", nil)); !bytes.Contains(b, []byte(`"type":"error"`)) {
+		t.Errorf("expected /healthz to be logged as an error. Got: %s", b)
+	}
+}
+
+func TestMiddlewareIgnoresInformationalStatus(t *testing.T) {
+	h := Middleware(logr.Default())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</app.css>; rel=preload")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	if _, b := serve(h, httptest.NewRequest(http.MethodPost, "/things", nil)); !bytes.Contains(b, []byte(`"status":201`)) {
+		t.Errorf("expected the final status to be logged. Got: %s", b)
+	}
+}
+
+func TestMiddlewareHijack(t *testing.T) {
+	buf := &bytes.Buffer{}
+	stop := logr.AddWriter(buf, logr.WithFormatter(logr.FormatJSON))
+	defer stop()
+
+	h := Middleware(logr.Default())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("expected the connection to be hijacked. Got: %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\nhello")
+		rw.Flush()
+	}))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n"))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("expected 101. Got: %d", resp.StatusCode)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !bytes.Contains(buf.Bytes(), []byte(`"status":101`)) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		logr.Wait()
+	}
+	if b := buf.Bytes(); !bytes.Contains(b, []byte(`"status":101`)) || !bytes.Contains(b, []byte(`"type":"info"`)) {
+		t.Errorf("expected the upgrade to be logged as info with status 101. Got: %s", b)
+	}
+}
+
+func TestMiddlewareReadFrom(t *testing.T) {
+	h := Middleware(logr.Default())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, strings.NewReader("copied"))
+	}))
+
+	w, b := serve(h, httptest.NewRequest(http.MethodGet, "/file", nil))
+	if w.Body.String() != "copied" || !bytes.Contains(b, []byte(`"bytes":6`)) {
+		t.Errorf("expected 6 bytes to be copied and logged. Got: %s %s", w.Body, b)
+	}
+}
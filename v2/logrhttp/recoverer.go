@@ -0,0 +1,40 @@
+package logrhttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/raisemarketplace/logr/v2"
+)
+
+// Recoverer returns net/http middleware that recovers from panics in the handlers it wraps. The panic is logged with
+// the request method, path and remote address, along with the request ID and any other meta data found in the request
+// context when it is wrapped by Middleware. The client receives a 500 response containing the code of the logged
+// message. http.ErrAbortHandler is passed on without being logged.
+func Recoverer(l logr.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				code := logr.LogRecovered(logr.WithCtx(l.With(logr.Meta{
+					"method":      r.Method,
+					"path":        r.URL.Path,
+					"remote_addr": r.RemoteAddr,
+				}), r.Context()), v)
+
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				w.Header().Set("X-Content-Type-Options", "nosniff")
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprintf(w, "internal server error: %s\n", code)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package logrhttp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/raisemarketplace/logr/v2"
+)
+
+func TestRecoverer(t *testing.T) {
+	h := Middleware(logr.Default())(Recoverer(logr.Default())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("TestRecoverer panic")
+	})))
+
+	r := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	r.Header.Set("X-Request-Id", "req-1")
+	w, b := serve(h, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500. Got: %d", w.Code)
+	}
+	for _, s := range []string{`"type":"panic"`, `"request_id":"req-1"`, `"path":"/panic"`, `"panic":"TestRecoverer panic"`, `"stack":"goroutine`} {
+		if !bytes.Contains(b, []byte(s)) {
+			t.Errorf("expected log to contain '%s'. Got: %s", s, b)
+		}
+	}
+	code := strings.TrimSpace(strings.TrimPrefix(w.Body.String(), "internal server error: "))
+	if code == "" || code == strings.TrimSpace(w.Body.String()) {
+		t.Fatalf("expected response to contain the message code. Got: %s", w.Body)
+	}
+	if !bytes.Contains(b, []byte(`"code":"`+code+`"`)) {
+		t.Errorf("expected the panic to be logged with code %s. Got: %s", code, b)
+	}
+	if !bytes.Contains(b, []byte(`"type":"error"`)) {
+		t.Errorf("expected the request to be logged as an error. Got: %s", b)
+	}
+}
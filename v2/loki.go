@@ -0,0 +1,314 @@
+package logr
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lokiPushPath is the path of the Loki push API
+const lokiPushPath = "/loki/api/v1/push"
+
+// LokiOverflow is the label value used for the values of a label beyond its cardinality limit
+const LokiOverflow = "_overflow"
+
+type LokiConfig struct {
+	http      []HTTPConfigModifier
+	labels    []string
+	typeLabel string
+	static    map[string]string
+	maxValues int
+	formatter Formatter
+}
+
+type LokiConfigModifier func(c LokiConfig) LokiConfig
+
+// WithLabels creates a LokiConfigModifier that promotes the given top-level Meta keys to stream labels. Promoted keys
+// are left out of the line. A key whose label name would be the same as the type label, a static label or the label of
+// an earlier key, such as user.id after user_id, is not promoted and stays in the line.
+func WithLabels(keys ...string) LokiConfigModifier {
+	return func(c LokiConfig) LokiConfig {
+		c.labels = append(append([]string(nil), c.labels...), keys...)
+		return c
+	}
+}
+
+// WithTypeLabel creates a LokiConfigModifier that sets the name of the label holding the message Type. An empty name
+// leaves the Type out of the labels. The default is level.
+func WithTypeLabel(name string) LokiConfigModifier {
+	return func(c LokiConfig) LokiConfig {
+		c.typeLabel = name
+		return c
+	}
+}
+
+// WithStaticLabels creates a LokiConfigModifier that adds labels to every stream, such as the application or
+// environment
+func WithStaticLabels(labels map[string]string) LokiConfigModifier {
+	return func(c LokiConfig) LokiConfig {
+		static := make(map[string]string, len(c.static)+len(labels))
+		for k, v := range c.static {
+			static[k] = v
+		}
+		for k, v := range labels {
+			static[lokiLabelName(k)] = v
+		}
+		c.static = static
+		return c
+	}
+}
+
+// WithMaxLabelValues creates a LokiConfigModifier that limits how many distinct values each promoted Meta key may
+// have, to guard Loki against high cardinality streams. Further values are labelled LokiOverflow and kept in the
+// line. The default is 100.
+func WithMaxLabelValues(n int) LokiConfigModifier {
+	return func(c LokiConfig) LokiConfig {
+		c.maxValues = n
+		return c
+	}
+}
+
+// WithTenant creates a LokiConfigModifier that sends the X-Scope-OrgID header for multi-tenant Loki
+func WithTenant(id string) LokiConfigModifier {
+	return func(c LokiConfig) LokiConfig {
+		c.http = append(append([]HTTPConfigModifier(nil), c.http...), WithHeader("X-Scope-OrgID", id))
+		return c
+	}
+}
+
+// WithLineFormatter creates a LokiConfigModifier that sets the Formatter of the line. The default is FormatLogfmt.
+func WithLineFormatter(f Formatter) LokiConfigModifier {
+	return func(c LokiConfig) LokiConfig {
+		c.formatter = f
+		return c
+	}
+}
+
+// WithLokiHTTP creates a LokiConfigModifier that configures the batching, retries and requests like an HTTPWriter.
+// The body format is always the Loki push format, and one batch is sent at a time whatever the concurrency, as Loki
+// rejects entries older than the last one it received for a stream.
+func WithLokiHTTP(configs ...HTTPConfigModifier) LokiConfigModifier {
+	return func(c LokiConfig) LokiConfig {
+		c.http = append(append([]HTTPConfigModifier(nil), c.http...), configs...)
+		return c
+	}
+}
+
+// LokiWriter is a MessageWriter that sends messages to the Grafana Loki push API. Messages are grouped into streams
+// by their labels and sent in batches like an HTTPWriter. The line is formatted by the line formatter, so the
+// Formatter given to AddWriter is not used. It implements Flusher and io.Closer.
+type LokiWriter struct {
+	c        LokiConfig
+	h        *HTTPWriter
+	promoted []lokiLabel
+
+	mu     sync.Mutex
+	seen   map[string]map[string]struct{}
+	static string
+}
+
+// NewLokiWriter creates a LokiWriter for the Loki server at url, e.g. http://loki:3100
+//
+//	w, err := logr.NewLokiWriter("http://loki:3100", logr.WithLabels("service"), logr.WithTenant("team-a"))
+//	...
+//	logr.AddWriter(w)
+//	defer logr.Close()
+func NewLokiWriter(url string, configs ...LokiConfigModifier) (*LokiWriter, error) {
+	// default config
+	c := LokiConfig{
+		typeLabel: "level",
+		maxValues: 100,
+		formatter: FormatLogfmt,
+	}
+	// apply optional extra config modifiers
+	for _, m := range configs {
+		c = m(c)
+	}
+
+	if !strings.HasSuffix(url, lokiPushPath) {
+		url = strings.TrimSuffix(url, "/") + lokiPushPath
+	}
+	enc := httpEncoder{contentType: "application/json", encode: encodeLokiPush}
+	hc, err := httpConfig(c.http)
+	if err != nil {
+		return nil, err
+	}
+	hc.concurrency = 1
+	w := &LokiWriter{
+		c:        c,
+		h:        newHTTPWriter(url, hc, enc),
+		promoted: lokiPromoted(c),
+		seen:     map[string]map[string]struct{}{},
+	}
+	w.static = string(appendJSONStringObject(nil, w.labels()))
+	return w, nil
+}
+
+// Write implements io.Writer. p is sent as the line of a stream with only the static labels.
+func (w *LokiWriter) Write(p []byte) (int, error) {
+	return w.h.add(httpEntry{line: bytes.TrimRight(p, "\n"), key: w.static, time: time.Now()}, len(p))
+}
+
+// WriteMessage implements MessageWriter. It promotes the labels of m and formats the rest into the line with the line
+// formatter. p is not used, and reported as written.
+func (w *LokiWriter) WriteMessage(m *Message, p []byte) (int, error) {
+	labels := w.labels()
+	if w.c.typeLabel != "" {
+		labels[w.c.typeLabel] = m.Type.String()
+	}
+	line := m
+	for _, l := range w.promoted {
+		k := l.key
+		v, ok := m.Meta[k]
+		if !ok {
+			continue
+		}
+		value := logfmtValue(v)
+		if !w.allow(k, value) {
+			labels[l.name] = LokiOverflow
+			continue
+		}
+		labels[l.name] = value
+		// the meta data is shared with the logger, so the line gets a copy without the label
+		if line == m {
+			line = m.copy()
+			line.Meta = make(MetaData, len(m.Meta))
+			for mk, mv := range m.Meta {
+				line.Meta[mk] = mv
+			}
+		}
+		delete(line.Meta, k)
+	}
+	e := httpEntry{
+		line: bytes.TrimRight(w.c.formatter(line), "\n"),
+		key:  string(appendJSONStringObject(nil, labels)),
+		time: messageTime(m),
+	}
+	return w.h.add(e, len(p))
+}
+
+// Flush implements Flusher
+func (w *LokiWriter) Flush() error {
+	return w.h.Flush()
+}
+
+// Close sends the current batch and waits for the batches in flight
+func (w *LokiWriter) Close() error {
+	return w.h.Close()
+}
+
+// Health implements HealthReporter
+func (w *LokiWriter) Health() WriterHealth {
+	return w.h.Health()
+}
+
+// lokiLabel is a Meta key promoted to a label
+type lokiLabel struct {
+	key  string
+	name string
+}
+
+// lokiPromoted returns the keys to promote with their label names, leaving out the keys whose label name is already
+// taken by the type label, a static label or an earlier key
+func lokiPromoted(c LokiConfig) []lokiLabel {
+	taken := make(map[string]bool, len(c.static)+len(c.labels)+1)
+	for k := range c.static {
+		taken[k] = true
+	}
+	if c.typeLabel != "" {
+		taken[c.typeLabel] = true
+	}
+	var promoted []lokiLabel
+	for _, k := range c.labels {
+		name := lokiLabelName(k)
+		if taken[name] {
+			continue
+		}
+		taken[name] = true
+		promoted = append(promoted, lokiLabel{key: k, name: name})
+	}
+	return promoted
+}
+
+// labels returns a new map holding the static labels
+func (w *LokiWriter) labels() map[string]string {
+	labels := make(map[string]string, len(w.c.static)+len(w.promoted)+1)
+	for k, v := range w.c.static {
+		labels[k] = v
+	}
+	return labels
+}
+
+// allow reports whether value may be used as a label value of key without going over the cardinality limit
+func (w *LokiWriter) allow(key, value string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	values, ok := w.seen[key]
+	if !ok {
+		values = map[string]struct{}{}
+		w.seen[key] = values
+	}
+	if _, ok := values[value]; ok {
+		return true
+	}
+	if len(values) >= w.c.maxValues {
+		return false
+	}
+	values[value] = struct{}{}
+	return true
+}
+
+// lokiLabelName replaces the characters that are not allowed in a Loki label name with underscores
+func lokiLabelName(key string) string {
+	b := []byte(key)
+	for i, c := range b {
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9' {
+			continue
+		}
+		b[i] = '_'
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
+
+// encodeLokiPush writes a Loki push request with the entries grouped into streams by their labels, in the order the
+// streams first appear in the batch
+func encodeLokiPush(buf *bytes.Buffer, batch []httpEntry) error {
+	var order []string
+	streams := map[string][]httpEntry{}
+	for _, e := range batch {
+		if _, ok := streams[e.key]; !ok {
+			order = append(order, e.key)
+		}
+		streams[e.key] = append(streams[e.key], e)
+	}
+
+	var scratch []byte
+	buf.WriteString(`{"streams":[`)
+	for i, key := range order {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`{"stream":`)
+		buf.WriteString(key)
+		buf.WriteString(`,"values":[`)
+		for j, e := range streams[key] {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			scratch = append(scratch[:0], `["`...)
+			scratch = strconv.AppendInt(scratch, e.time.UnixNano(), 10)
+			scratch = append(scratch, `",`...)
+			scratch = appendJSONString(scratch, string(e.line))
+			scratch = append(scratch, ']')
+			buf.Write(scratch)
+		}
+		buf.WriteString(`]}`)
+	}
+	buf.WriteString(`]}`)
+	return nil
+}
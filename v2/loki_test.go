@@ -0,0 +1,169 @@
+package logr
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lokiPush is the body of a Loki push request
+type lokiPush struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	} `json:"streams"`
+}
+
+func TestLokiWriterStreams(t *testing.T) {
+	rec := &httpRecorder{}
+	s := httptest.NewServer(rec)
+	defer s.Close()
+	w, err := NewLokiWriter(s.URL+"/",
+		WithLabels("service", "user.id"),
+		WithStaticLabels(map[string]string{"env-name": "test"}),
+		WithMaxLabelValues(2),
+		WithTenant("team-a"),
+		WithLineFormatter(func(m *Message) []byte { return []byte(m.Desc + " " + logfmtValue(m.Meta) + "\n") }),
+		WithLokiHTTP(WithFlushInterval(0)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	for i, m := range []*Message{
+		{Type: I, Desc: "one", Meta: MetaData{"service": "api", "user.id": 1}},
+		{Type: E, Desc: "two", Meta: MetaData{"service": "api"}},
+		{Type: I, Desc: "three", Meta: MetaData{"service": "web", "user.id": 1}},
+		{Type: I, Desc: "four", Meta: MetaData{"service": "db", "user.id": 1}},
+	} {
+		m.Timestamp = at.Add(time.Duration(i) * time.Second)
+		if _, err := w.WriteMessage(m, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Write([]byte("raw\n"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rec.bodies) != 1 {
+		t.Fatalf("expected a single request. Got: %q", rec.bodies)
+	}
+	if rec.headers[0].Get("X-Scope-OrgID") != "team-a" {
+		t.Errorf("expected the tenant header. Got: %v", rec.headers[0])
+	}
+	var push lokiPush
+	if err := json.Unmarshal([]byte(rec.bodies[0]), &push); err != nil {
+		t.Fatalf("expected a valid push request: %v\n%s", err, rec.bodies[0])
+	}
+	var got []string
+	for _, st := range push.Streams {
+		b, _ := json.Marshal(st.Stream)
+		for _, v := range st.Values {
+			got = append(got, string(b)+" "+v[0]+" "+v[1])
+		}
+	}
+	want := []string{
+		`{"env_name":"test","level":"info","service":"api","user_id":"1"} 1792324800000000000 one map[]`,
+		`{"env_name":"test","level":"error","service":"api"} 1792324801000000000 two map[]`,
+		`{"env_name":"test","level":"info","service":"web","user_id":"1"} 1792324802000000000 three map[]`,
+		`{"env_name":"test","level":"info","service":"_overflow","user_id":"1"} 1792324803000000000 four map[service:db]`,
+		`{"env_name":"test"}`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d entries. Got: %q", len(want), got)
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("expected %s. Got: %s", want[i], got[i])
+		}
+	}
+	if !strings.HasSuffix(got[4], " raw") {
+		t.Errorf("expected the raw write as the line. Got: %s", got[4])
+	}
+}
+
+func TestLokiWriterReceivesMessages(t *testing.T) {
+	rec := &httpRecorder{}
+	s := httptest.NewServer(rec)
+	defer s.Close()
+	w, err := NewLokiWriter(s.URL+lokiPushPath, WithLabels("loki_test"), WithLokiHTTP(WithFlushInterval(0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	stop := AddWriter(w)
+	defer stop()
+
+	meta := Meta{"loki_test": "yes", "user": 42}
+	With(meta).Info("TestLokiWriterReceivesMessages")
+	Wait()
+	w.Flush()
+	var push lokiPush
+	if len(rec.bodies) != 1 || json.Unmarshal([]byte(rec.bodies[0]), &push) != nil || len(push.Streams) != 1 {
+		t.Fatalf("expected a single stream. Got: %q", rec.bodies)
+	}
+	line := push.Streams[0].Values[0][1]
+	if push.Streams[0].Stream["loki_test"] != "yes" || strings.Contains(line, "loki_test") ||
+		!strings.Contains(line, "msg=TestLokiWriterReceivesMessages") || !strings.Contains(line, "user=42") {
+		t.Errorf("expected the label to be promoted out of the logfmt line. Got: %+v", push.Streams[0])
+	}
+	if _, ok := meta["loki_test"]; !ok {
+		t.Errorf("expected the logger meta data to be left alone")
+	}
+}
+
+func TestLokiLabelName(t *testing.T) {
+	tests := map[string]string{
+		"service":  "service",
+		"user.id":  "user_id",
+		"2fa":      "_fa",
+		"a9":       "a9",
+		"":         "_",
+		"héllo":    "h__llo",
+		"k8s-node": "k8s_node",
+	}
+	for in, want := range tests {
+		if got := lokiLabelName(in); got != want {
+			t.Errorf("expected %q to be %q. Got: %q", in, want, got)
+		}
+	}
+}
+
+func TestLokiWriterLabelCollisions(t *testing.T) {
+	rec := &httpRecorder{}
+	s := httptest.NewServer(rec)
+	defer s.Close()
+	w, err := NewLokiWriter(s.URL,
+		WithLabels("level", "env.name", "a_b", "a.b"),
+		WithStaticLabels(map[string]string{"env-name": "test"}),
+		WithLineFormatter(func(m *Message) []byte { return []byte(logfmtValue(m.Meta)) }),
+		WithLokiHTTP(WithFlushInterval(0), WithConcurrency(4)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.h.c.concurrency != 1 {
+		t.Errorf("expected one batch at a time. Got: %d", w.h.c.concurrency)
+	}
+
+	m := &Message{Type: E, Timestamp: time.Now(), Meta: MetaData{"level": "debug", "env.name": "prod", "a_b": 1, "a.b": 2}}
+	w.WriteMessage(m, nil)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var push lokiPush
+	if len(rec.bodies) != 1 || json.Unmarshal([]byte(rec.bodies[0]), &push) != nil || len(push.Streams) != 1 {
+		t.Fatalf("expected a single stream. Got: %q", rec.bodies)
+	}
+	b, _ := json.Marshal(push.Streams[0].Stream)
+	if string(b) != `{"a_b":"1","env_name":"test","level":"error"}` {
+		t.Errorf("expected the colliding keys not to replace labels. Got: %s", b)
+	}
+	if line := push.Streams[0].Values[0][1]; line != "map[a.b:2 env.name:prod level:debug]" {
+		t.Errorf("expected the colliding keys in the line. Got: %s", line)
+	}
+}
@@ -1,5 +1,7 @@
 package logr
 
+import "time"
+
 const (
 	// ColourReset code
 	ColourReset = "\x1B[0m"
@@ -7,14 +9,16 @@ const (
 
 type MetaData Meta
 
-// Message used to send log message to logger goroutine
+// Message used to send log message to logger goroutine. Timestamp holds the time the message was logged for
+// formatters that need a machine readable time.
 type Message struct {
-	Type Type          `json:"type"`
-	Time string        `json:"time"`
-	Code string        `json:"code"`
-	Desc string        `json:"description"`
-	Meta MetaData      `json:"metadata,omitempty"`
-	done chan struct{} `json:"-"`
+	Type      Type          `json:"type"`
+	Time      string        `json:"time"`
+	Code      string        `json:"code"`
+	Desc      string        `json:"description"`
+	Meta      MetaData      `json:"metadata,omitempty"`
+	Timestamp time.Time     `json:"-"`
+	done      chan struct{} `json:"-"`
 }
 
 // Reset the message object for later reuse
@@ -24,5 +28,21 @@ func (m *Message) Reset() {
 	m.Code = ""
 	m.Desc = ""
 	m.Meta = nil
+	m.Timestamp = time.Time{}
 	m.done = nil
 }
+
+// copy returns a copy of the message that is not pooled or waited on. The meta data is shared.
+func (m *Message) copy() *Message {
+	c := *m
+	c.done = nil
+	return &c
+}
+
+// messageTime returns the time a message was logged, or the current time if the Message has no Timestamp
+func messageTime(m *Message) time.Time {
+	if m.Timestamp.IsZero() {
+		return time.Now()
+	}
+	return m.Timestamp
+}
@@ -1,5 +1,10 @@
 package logr
 
+import (
+	"reflect"
+	"sort"
+)
+
 func M(key string, value any) Meta {
 	return Meta{}.With(key, value)
 }
@@ -18,3 +23,42 @@ func (m Meta) Copy() Meta {
 	}
 	return meta
 }
+
+// flatten calls fn for every value in data in key order, descending into nested maps with string keys. The keys of
+// nested values are joined to their parents' keys with a dot. Like FormatPretty, maps are only descended into up to
+// prettyMaxDepth, and a map containing itself is passed to fn as <cycle> instead of recursing forever.
+func flatten(data map[string]any, fn func(key string, value any)) {
+	flattenValue("", data, fn, 0, map[uintptr]bool{})
+}
+
+func flattenValue(key string, value any, fn func(key string, value any), depth int, visited map[uintptr]bool) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		fn(key, value)
+		return
+	}
+	id := rv.Pointer()
+	switch {
+	case visited[id]:
+		fn(key, "<cycle>")
+		return
+	case depth > prettyMaxDepth:
+		fn(key, "<max depth>")
+		return
+	}
+	visited[id] = true
+	defer delete(visited, id)
+
+	keys := make([]string, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		nk := k
+		if key != "" {
+			nk = key + "." + k
+		}
+		flattenValue(nk, rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).Interface(), fn, depth+1, visited)
+	}
+}
@@ -0,0 +1,454 @@
+package logr
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxDatagramSize is the largest message a NetWriter sends as a datagram, the maximum payload of a udp datagram over
+// IPv4
+const maxDatagramSize = 65507
+
+// Framing defines how a NetWriter separates messages on the connection
+type Framing int
+
+// Available framings
+const (
+	FramingNewline      Framing = iota // each message ends with a single newline
+	FramingLengthPrefix                // each message is preceded by its length as a 4 byte big-endian integer
+	FramingNone                        // messages are written as they are, for datagram networks
+	FramingOctetCount                  // each message is preceded by its length in decimal and a space (RFC 6587)
+)
+
+type NetConfig struct {
+	framing      Framing
+	dialTimeout  time.Duration
+	writeTimeout time.Duration
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	bufferLimit  int
+	tls          *tls.Config
+}
+
+type NetConfigModifier func(c NetConfig) NetConfig
+
+// WithFraming creates a NetConfigModifier that sets how messages are separated. The default is FramingNewline.
+func WithFraming(f Framing) NetConfigModifier {
+	return func(c NetConfig) NetConfig {
+		c.framing = f
+		return c
+	}
+}
+
+// WithDialTimeout creates a NetConfigModifier that sets how long connecting may take. The default is 5 seconds.
+func WithDialTimeout(d time.Duration) NetConfigModifier {
+	return func(c NetConfig) NetConfig {
+		c.dialTimeout = d
+		return c
+	}
+}
+
+// WithWriteTimeout creates a NetConfigModifier that sets the deadline of each write. A write that misses it is
+// treated as a broken connection. The default is 5 seconds.
+func WithWriteTimeout(d time.Duration) NetConfigModifier {
+	return func(c NetConfig) NetConfig {
+		c.writeTimeout = d
+		return c
+	}
+}
+
+// WithBackoff creates a NetConfigModifier that sets the delay before reconnecting after a failure, which doubles after
+// every failed attempt from min up to max. The default is 100ms to 30s.
+func WithBackoff(min, max time.Duration) NetConfigModifier {
+	return func(c NetConfig) NetConfig {
+		c.minBackoff = min
+		c.maxBackoff = max
+		return c
+	}
+}
+
+// WithBufferLimit creates a NetConfigModifier that sets how many bytes of messages are kept in memory while the
+// connection is down. The oldest messages are dropped to stay within the limit. The default is 1MB.
+func WithBufferLimit(n int) NetConfigModifier {
+	return func(c NetConfig) NetConfig {
+		c.bufferLimit = n
+		return c
+	}
+}
+
+// WithTLS creates a NetConfigModifier that connects using TLS with the given configuration. It is only supported on
+// tcp networks.
+func WithTLS(cfg *tls.Config) NetConfigModifier {
+	return func(c NetConfig) NetConfig {
+		c.tls = cfg
+		return c
+	}
+}
+
+// NetWriter is an io.Writer that sends messages over a tcp, udp or unix socket. It connects in the background after
+// the first write and reconnects with backoff when the connection breaks, keeping messages in memory until it is
+// back, so writes never wait for a connection. Messages that can never be sent, such as datagrams over 65507 bytes or
+// ones the network rejects as too large, are dropped. Its state is reported in Stats. It implements Flusher and
+// io.Closer.
+type NetWriter struct {
+	c       NetConfig
+	network string
+	addr    string
+
+	// dialMu serializes connecting, so it happens outside mu without two connections being made at once
+	dialMu sync.Mutex
+
+	mu      sync.Mutex
+	conn    net.Conn
+	pending [][]byte
+	size    int
+	down    bool
+	dialled bool
+	closing bool
+	closed  bool
+	health  WriterHealth
+	backoff time.Duration
+
+	retry chan struct{}
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewNetWriter creates a NetWriter for the given network and address. Supported networks are tcp, tcp4, tcp6, udp,
+// udp4, udp6, unix, unixgram and unixpacket. It does not connect until the first write.
+//
+//	w, err := logr.NewNetWriter("tcp", "logs.internal:5170", logr.WithTLS(&tls.Config{}))
+//	...
+//	logr.AddWriter(w, logr.WithFormatter(logr.FormatJSON))
+func NewNetWriter(network, addr string, configs ...NetConfigModifier) (*NetWriter, error) {
+	// default config
+	c := NetConfig{
+		dialTimeout:  5 * time.Second,
+		writeTimeout: 5 * time.Second,
+		minBackoff:   100 * time.Millisecond,
+		maxBackoff:   30 * time.Second,
+		bufferLimit:  1 << 20,
+	}
+	// apply optional extra config modifiers
+	for _, m := range configs {
+		c = m(c)
+	}
+
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	case "udp", "udp4", "udp6", "unix", "unixgram", "unixpacket":
+		if c.tls != nil {
+			return nil, fmt.Errorf("logr: tls is not supported on network %q", network)
+		}
+	default:
+		return nil, fmt.Errorf("logr: unsupported network %q", network)
+	}
+	w := &NetWriter{
+		c:       c,
+		network: network,
+		addr:    addr,
+		health:  WriterHealth{Name: network + "://" + addr, Healthy: true},
+		backoff: c.minBackoff,
+		retry:   make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+	registerHealth(w)
+	w.wg.Add(1)
+	go w.reconnect()
+	return w, nil
+}
+
+// Write sends p as one message. Until the connection is made, and while it is down, the message is buffered and
+// Write returns no error, as it is sent when the connection is back.
+func (w *NetWriter) Write(p []byte) (int, error) {
+	frame := w.frame(p)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, errors.New("logr: write to closed NetWriter")
+	}
+	if !w.stream() && len(frame) > maxDatagramSize {
+		w.drop(fmt.Errorf("logr: message of %d bytes is too large for a datagram", len(frame)))
+		return len(p), nil
+	}
+	if w.conn == nil {
+		w.buffer(frame)
+		w.connect()
+		return len(p), nil
+	}
+	if err := w.send(w.conn, frame); err != nil {
+		if frameRejected(err) {
+			w.drop(err)
+		} else {
+			w.fail(err, frame)
+		}
+	}
+	return len(p), nil
+}
+
+// Flush implements Flusher. It tries to send the buffered messages straight away, and returns an error if the
+// connection is down.
+func (w *NetWriter) Flush() error {
+	w.mu.Lock()
+	waiting := w.conn == nil && len(w.pending) > 0 && !w.closed
+	w.mu.Unlock()
+	if waiting {
+		w.resume()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.down {
+		return fmt.Errorf("logr: %s is down with %d bytes buffered: %s", w.health.Name, w.size, w.health.LastError)
+	}
+	return nil
+}
+
+// Close tries to send the buffered messages, closes the connection and removes the NetWriter from Stats. Closing it
+// again does nothing.
+func (w *NetWriter) Close() error {
+	w.mu.Lock()
+	if w.closing || w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closing = true
+	w.mu.Unlock()
+	close(w.stop)
+	w.wg.Wait()
+	w.resume()
+
+	w.mu.Lock()
+	w.closed = true
+	var err error
+	if w.size > 0 {
+		err = fmt.Errorf("logr: %s closed with %d bytes undelivered", w.health.Name, w.size)
+	}
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+	w.mu.Unlock()
+	unregisterHealth(w)
+	return err
+}
+
+// Health implements HealthReporter
+func (w *NetWriter) Health() WriterHealth {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	h := w.health
+	h.Buffered = w.size
+	return h
+}
+
+// frame returns p framed for the connection
+func (w *NetWriter) frame(p []byte) []byte {
+	msg := bytes.TrimRight(p, "\n")
+	switch w.c.framing {
+	case FramingLengthPrefix:
+		frame := make([]byte, 4, len(msg)+4)
+		binary.BigEndian.PutUint32(frame, uint32(len(msg)))
+		return append(frame, msg...)
+	case FramingNone:
+		return append([]byte(nil), p...)
+	case FramingOctetCount:
+		frame := strconv.AppendInt(make([]byte, 0, len(msg)+8), int64(len(msg)), 10)
+		frame = append(frame, ' ')
+		return append(frame, msg...)
+	default:
+		frame := make([]byte, 0, len(msg)+1)
+		frame = append(frame, msg...)
+		return append(frame, '\n')
+	}
+}
+
+// dial connects to the address
+func (w *NetWriter) dial() (net.Conn, error) {
+	d := &net.Dialer{Timeout: w.c.dialTimeout}
+	if w.c.tls != nil {
+		return tls.DialWithDialer(d, w.network, w.addr, w.c.tls)
+	}
+	return d.Dial(w.network, w.addr)
+}
+
+// send writes a frame with the write deadline
+func (w *NetWriter) send(conn net.Conn, frame []byte) error {
+	if w.c.writeTimeout > 0 {
+		conn.SetWriteDeadline(time.Now().Add(w.c.writeTimeout))
+	}
+	_, err := conn.Write(frame)
+	return err
+}
+
+// connect has the connection made in the background. It is called with mu held.
+func (w *NetWriter) connect() {
+	select {
+	case w.retry <- struct{}{}:
+	default:
+	}
+}
+
+// fail records a delivery error, buffers the frame and has the connection retried in the background. It is called
+// with mu held.
+func (w *NetWriter) fail(err error, frame []byte) {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+	w.down = true
+	w.health.Healthy = false
+	w.health.LastError = err.Error()
+	w.health.LastErrorTime = time.Now()
+	if frame != nil {
+		w.buffer(frame)
+	}
+	w.connect()
+}
+
+// drop records a frame that can never be sent as dropped. It is called with mu held.
+func (w *NetWriter) drop(err error) {
+	w.health.Dropped++
+	w.health.LastError = err.Error()
+	w.health.LastErrorTime = time.Now()
+}
+
+// buffer keeps a frame until the connection is back, dropping the oldest frames to stay within the buffer limit. It
+// is called with mu held.
+func (w *NetWriter) buffer(frame []byte) {
+	if len(frame) > w.c.bufferLimit {
+		w.health.Dropped++
+		return
+	}
+	w.pending = append(w.pending, frame)
+	w.size += len(frame)
+	for w.size > w.c.bufferLimit {
+		w.size -= len(w.pending[0])
+		w.pending[0] = nil
+		w.pending = w.pending[1:]
+		w.health.Dropped++
+	}
+}
+
+// resume connects and sends the buffered frames, reporting whether the connection is up. Connecting and sending
+// happen without mu held, so writes are buffered meanwhile instead of waiting.
+func (w *NetWriter) resume() bool {
+	w.dialMu.Lock()
+	defer w.dialMu.Unlock()
+	w.mu.Lock()
+	up := w.closed || w.conn != nil
+	w.mu.Unlock()
+	if up {
+		return true
+	}
+
+	conn, err := w.dial()
+	if err != nil {
+		w.mu.Lock()
+		w.fail(err, nil)
+		w.mu.Unlock()
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// frames written while draining are buffered, so drain until there are none left
+	for len(w.pending) > 0 {
+		batch, size := w.pending, w.size
+		w.pending, w.size = nil, 0
+		w.mu.Unlock()
+		sent, err := 0, error(nil)
+		var rejected []error
+		for ; sent < len(batch) && err == nil; sent++ {
+			if err = w.send(conn, batch[sent]); err != nil && frameRejected(err) {
+				rejected, err = append(rejected, err), nil
+			}
+		}
+		w.mu.Lock()
+		for _, r := range rejected {
+			w.drop(r)
+		}
+		if err != nil {
+			// splice the frames that were not sent back in front of the ones written meanwhile
+			for _, f := range batch[:sent-1] {
+				size -= len(f)
+			}
+			w.pending = append(batch[sent-1:], w.pending...)
+			w.size += size
+			conn.Close()
+			w.fail(err, nil)
+			return false
+		}
+	}
+	w.pending = nil
+	w.conn = conn
+	if w.down || w.dialled {
+		w.health.Reconnects++
+	}
+	w.down, w.dialled = false, true
+	w.health.Healthy = true
+	w.backoff = w.c.minBackoff
+	if w.stream() {
+		go w.watch(conn)
+	}
+	return true
+}
+
+// watch reads from a stream connection until it fails, so a connection closed by the peer is noticed, reported as
+// unhealthy and made again before the next write is lost on it. Anything the peer sends is discarded.
+func (w *NetWriter) watch(conn net.Conn) {
+	b := make([]byte, 512)
+	for {
+		if _, err := conn.Read(b); err != nil {
+			break
+		}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == conn {
+		w.fail(fmt.Errorf("logr: connection to %s closed by peer", w.health.Name), nil)
+	}
+}
+
+// stream reports whether the network is connection oriented
+func (w *NetWriter) stream() bool {
+	switch w.network {
+	case "tcp", "tcp4", "tcp6", "unix", "unixpacket":
+		return true
+	}
+	return false
+}
+
+// reconnect makes the connection, retrying with backoff while it fails, every time it is needed until the NetWriter
+// is closed
+func (w *NetWriter) reconnect() {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.retry:
+		}
+		for !w.resume() {
+			w.mu.Lock()
+			delay := w.backoff
+			if w.backoff *= 2; w.backoff > w.c.maxBackoff {
+				w.backoff = w.c.maxBackoff
+			}
+			w.mu.Unlock()
+			t := time.NewTimer(delay)
+			select {
+			case <-w.stop:
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !plan9
+
+package logr
+
+import (
+	"errors"
+	"syscall"
+)
+
+// frameRejected reports whether err means the frame itself could not be sent, such as a datagram that is too large,
+// rather than the connection being broken, so sending it again would fail the same way
+func frameRejected(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE)
+}
@@ -0,0 +1,9 @@
+//go:build plan9
+
+package logr
+
+// frameRejected reports whether err means the frame itself could not be sent. There is no EMSGSIZE on this platform,
+// so every error is treated as a broken connection.
+func frameRejected(err error) bool {
+	return false
+}
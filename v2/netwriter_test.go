@@ -0,0 +1,267 @@
+package logr
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a local tcp address that nothing is listening on
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+// acceptOne accepts a single connection on l
+func acceptOne(t *testing.T, l net.Listener) net.Conn {
+	l.(interface{ SetDeadline(time.Time) error }).SetDeadline(time.Now().Add(5 * time.Second))
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func TestNetWriterBuffersUntilConnected(t *testing.T) {
+	addr := freeAddr(t)
+	w, err := NewNetWriter("tcp", addr, WithBackoff(time.Millisecond, 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	for _, line := range []string{"one\n", "two", "three\n\n"} {
+		if n, err := w.Write([]byte(line)); err != nil || n != len(line) {
+			t.Fatalf("expected the write to be buffered. Got: %d, %v", n, err)
+		}
+	}
+	if err := w.Flush(); err == nil {
+		t.Errorf("expected Flush to fail without a connection")
+	}
+	h := w.Health()
+	if h.Healthy || h.Buffered != len("one\ntwo\nthree\n") || h.LastError == "" || h.LastErrorTime.IsZero() {
+		t.Errorf("expected the writer to be down with the lines buffered. Got: %+v", h)
+	}
+	found := false
+	for _, h := range Stats().Health {
+		found = found || h.Name == "tcp://"+addr && !h.Healthy
+	}
+	if !found {
+		t.Errorf("expected the writer in the pipeline stats. Got: %+v", Stats().Health)
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	conn := acceptOne(t, l)
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for _, want := range []string{"one\n", "two\n", "three\n"} {
+		if got, err := r.ReadString('\n'); err != nil || got != want {
+			t.Fatalf("expected %q. Got: %q, %v", want, got, err)
+		}
+	}
+	if _, err := w.Write([]byte("four\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := r.ReadString('\n'); got != "four\n" {
+		t.Errorf("expected writes to go straight to the connection. Got: %q", got)
+	}
+	if h := w.Health(); !h.Healthy || h.Buffered != 0 || h.Reconnects != 1 {
+		t.Errorf("expected the writer to be healthy again. Got: %+v", h)
+	}
+
+	w.Close()
+	for _, h := range Stats().Health {
+		if h.Name == "tcp://"+addr {
+			t.Errorf("expected Close to remove the writer from the pipeline stats")
+		}
+	}
+	if _, err := w.Write([]byte("late\n")); err == nil {
+		t.Errorf("expected writes after Close to fail")
+	}
+}
+
+func TestNetWriterBufferLimit(t *testing.T) {
+	w, err := NewNetWriter("tcp", freeAddr(t), WithBufferLimit(10), WithBackoff(time.Hour, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	for _, line := range []string{"aaaa", "bbbb", "cccc", "this line is too long"} {
+		w.Write([]byte(line))
+	}
+	if h := w.Health(); h.Buffered != 10 || h.Dropped != 2 {
+		t.Errorf("expected the oldest line and the long line to be dropped. Got: %+v", h)
+	}
+	if string(w.pending[0]) != "bbbb\n" {
+		t.Errorf("expected the oldest line to be dropped. Got: %q", w.pending)
+	}
+	if err := w.Flush(); err == nil || !strings.Contains(err.Error(), "10 bytes buffered") {
+		t.Errorf("expected Flush to report the buffered bytes. Got: %v", err)
+	}
+}
+
+func TestNetWriterFraming(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	w, err := NewNetWriter("tcp", l.Addr().String(), WithFraming(FramingLengthPrefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("hello\n"))
+	w.Write([]byte("multi\nline\n"))
+	conn := acceptOne(t, l)
+	defer conn.Close()
+	for _, want := range []string{"hello", "multi\nline"} {
+		var size uint32
+		if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+			t.Fatal(err)
+		}
+		b := make([]byte, size)
+		if _, err := io.ReadFull(conn, b); err != nil || string(b) != want {
+			t.Errorf("expected %q. Got: %q, %v", want, b, err)
+		}
+	}
+}
+
+func TestNetWriterTLS(t *testing.T) {
+	s := httptest.NewTLSServer(http.NotFoundHandler())
+	defer s.Close()
+	l, err := tls.Listen("tcp", "127.0.0.1:0", s.TLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	cfg := s.Client().Transport.(*http.Transport).TLSClientConfig
+	w, err := NewNetWriter("tcp", l.Addr().String(), WithTLS(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// the handshake happens on the first write, so accept concurrently
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			err = conn.(*tls.Conn).Handshake()
+		}
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- conn
+	}()
+	w.Write([]byte("secret\n"))
+	conn, ok := <-accepted
+	if !ok {
+		t.Fatal("expected a tls connection")
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if got, err := bufio.NewReader(conn).ReadString('\n'); err != nil || got != "secret\n" {
+		t.Errorf("expected the line over tls. Got: %q, %v", got, err)
+	}
+	if h := w.Health(); !h.Healthy {
+		t.Errorf("expected the writer to be healthy. Got: %+v", h)
+	}
+}
+
+func TestNewNetWriterErrors(t *testing.T) {
+	if _, err := NewNetWriter("ip4:icmp", "127.0.0.1"); err == nil {
+		t.Errorf("expected an error for an unsupported network")
+	}
+	if _, err := NewNetWriter("udp", "127.0.0.1:514", WithTLS(&tls.Config{})); err == nil {
+		t.Errorf("expected an error for tls over udp")
+	}
+}
+
+func TestNetWriterDropsOversizedDatagrams(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	w, err := NewNetWriter("udp", conn.LocalAddr().String(), WithFraming(FramingNone))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.Write([]byte(strings.Repeat("x", maxDatagramSize+1)))
+	w.Write([]byte("small"))
+	w.Flush()
+
+	b := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "small" {
+		t.Errorf("expected the small datagram to be sent. Got: %q", b[:n])
+	}
+	if h := w.Health(); h.Dropped != 1 || !strings.Contains(h.LastError, "too large") {
+		t.Errorf("expected the oversized datagram to be dropped. Got: %+v", h)
+	}
+}
+
+func TestNetWriterPeerClose(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	w, err := NewNetWriter("tcp", l.Addr().String(), WithBackoff(time.Hour, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("first"))
+	w.Flush()
+	acceptOne(t, l).Close()
+	l.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for w.Health().Healthy && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if h := w.Health(); h.Healthy || h.LastError == "" {
+		t.Errorf("expected the connection closed by the peer to be reported as unhealthy. Got: %+v", h)
+	}
+
+	// concurrent closes must not close the stop channel twice
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		go func() {
+			w.Close()
+			done <- struct{}{}
+		}()
+	}
+	<-done
+	<-done
+	if err := w.Close(); err != nil {
+		t.Errorf("expected closing again to do nothing. Got: %v", err)
+	}
+}
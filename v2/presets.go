@@ -0,0 +1,150 @@
+package logr
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ECSVersion is the Elastic Common Schema version FormatECS documents conform to
+const ECSVersion = "8.11.0"
+
+var (
+	// typeGoogleSeverityMap maps log types onto Google Cloud Logging severities
+	typeGoogleSeverityMap = map[Type]string{
+		None: "DEFAULT",
+		P:    "CRITICAL",
+		E:    "ERROR",
+		W:    "WARNING",
+		I:    "INFO",
+		D:    "DEBUG",
+		S:    "NOTICE",
+	}
+	// typeDatadogStatusMap maps log types onto Datadog statuses
+	typeDatadogStatusMap = map[Type]string{
+		None: "info",
+		P:    "critical",
+		E:    "error",
+		W:    "warn",
+		I:    "info",
+		D:    "debug",
+		S:    "ok",
+	}
+)
+
+// FormatECS is a Formatter that converts a Message to an Elastic Common Schema json document. The meta data is
+// written to labels, except for trace_id and span_id which are written to the ECS trace and span fields. ECS labels
+// are flat keyword values, so nested maps are flattened into keys joined with underscores, dots in keys are replaced
+// with underscores and every value is written as a string.
+func FormatECS(m *Message) []byte {
+	doc := map[string]any{
+		"@timestamp":  messageTime(m).UTC().Format(time.RFC3339Nano),
+		"log.level":   m.Type.String(),
+		"message":     m.Desc,
+		"event.id":    m.Code,
+		"ecs.version": ECSVersion,
+	}
+	labels := map[string]string{}
+	flatten(m.Meta, func(key string, value any) {
+		switch key {
+		case "trace_id":
+			doc["trace.id"] = value
+		case "span_id":
+			doc["span.id"] = value
+		default:
+			labels[strings.ReplaceAll(key, ".", "_")] = logfmtValue(value)
+		}
+	})
+	if len(labels) > 0 {
+		doc["labels"] = labels
+	}
+	return marshalLine("FormatECS", doc)
+}
+
+var defaultGoogleCloud = NewGoogleCloudFormatter("")
+
+// FormatGoogleCloud is a Formatter that converts a Message to a json structured log entry understood by Google Cloud
+// Logging. The meta data is written to the top level of the entry, except for trace_id and span_id which are written
+// to the logging.googleapis.com/trace and logging.googleapis.com/spanId special fields. Cloud Logging only links the
+// trace if it is the full projects/PROJECT_ID/traces/TRACE_ID name, so either log trace_id as that name or use
+// NewGoogleCloudFormatter with the project ID.
+func FormatGoogleCloud(m *Message) []byte {
+	return defaultGoogleCloud(m)
+}
+
+// NewGoogleCloudFormatter creates a Formatter like FormatGoogleCloud that writes trace IDs as the full trace name in
+// the given project, e.g. projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736. Trace IDs that are already
+// full names are written as they are.
+func NewGoogleCloudFormatter(projectID string) Formatter {
+	prefix := ""
+	if projectID != "" {
+		prefix = "projects/" + projectID + "/traces/"
+	}
+	return func(m *Message) []byte {
+		doc := map[string]any{}
+		for k, v := range m.Meta {
+			doc[k] = v
+		}
+		if v, ok := m.Meta["trace_id"]; ok {
+			delete(doc, "trace_id")
+			if s, ok := v.(string); ok && prefix != "" && !strings.HasPrefix(s, "projects/") {
+				v = prefix + s
+			}
+			doc["logging.googleapis.com/trace"] = v
+		}
+		if v, ok := m.Meta["span_id"]; ok {
+			delete(doc, "span_id")
+			doc["logging.googleapis.com/spanId"] = v
+		}
+		doc["severity"] = typeGoogleSeverityMap[m.Type]
+		doc["message"] = m.Desc
+		doc["time"] = messageTime(m).UTC().Format(time.RFC3339Nano)
+		doc["logging.googleapis.com/insertId"] = m.Code
+		return marshalLine("FormatGoogleCloud", doc)
+	}
+}
+
+// FormatDatadog is a Formatter that converts a Message to a json log understood by Datadog. The meta data is written
+// to the top level of the log. W3C trace_id and span_id values are converted to the decimal dd.trace_id and
+// dd.span_id fields Datadog uses to correlate logs with traces.
+func FormatDatadog(m *Message) []byte {
+	doc := map[string]any{}
+	for k, v := range m.Meta {
+		doc[k] = v
+	}
+	if id, ok := datadogID(m.Meta["trace_id"]); ok {
+		doc["dd.trace_id"] = id
+	}
+	if id, ok := datadogID(m.Meta["span_id"]); ok {
+		doc["dd.span_id"] = id
+	}
+	doc["status"] = typeDatadogStatusMap[m.Type]
+	doc["message"] = m.Desc
+	doc["timestamp"] = messageTime(m).UnixMilli()
+	doc["code"] = m.Code
+	return marshalLine("FormatDatadog", doc)
+}
+
+// datadogID converts a hex W3C trace or span ID to the decimal representation of its lower 64 bits
+func datadogID(v any) (string, bool) {
+	s, ok := v.(string)
+	if !ok || len(s) < 16 {
+		return "", false
+	}
+	id, err := strconv.ParseUint(s[len(s)-16:], 16, 64)
+	if err != nil {
+		return "", false
+	}
+	return strconv.FormatUint(id, 10), true
+}
+
+// marshalLine converts v to json followed by a newline
+func marshalLine(name string, v any) []byte {
+	r, err := json.Marshal(v)
+	if err != nil {
+		fmt.Printf("failed to marshal Message in logr.%s: %v\n", name, err)
+	}
+	return append(r, '\n')
+}
@@ -0,0 +1,128 @@
+package logr
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func presetTestMessage() *Message {
+	ts := time.Date(2026, 10, 17, 14, 5, 6, 7000000, time.UTC)
+	return &Message{
+		Type:      W,
+		Time:      ts.Format(TimeFormat),
+		Code:      "abc123",
+		Desc:      "slow request",
+		Timestamp: ts,
+		Meta: MetaData{
+			"user":     "u1",
+			"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span_id":  "00f067aa0ba902b7",
+		},
+	}
+}
+
+func TestPresetFormatters(t *testing.T) {
+	tests := []struct {
+		name string
+		f    Formatter
+		want map[string]any
+	}{
+		{
+			name: "ECS",
+			f:    FormatECS,
+			want: map[string]any{
+				"@timestamp":  "2026-10-17T14:05:06.007Z",
+				"log.level":   "warning",
+				"message":     "slow request",
+				"event.id":    "abc123",
+				"ecs.version": ECSVersion,
+				"trace.id":    "4bf92f3577b34da6a3ce929d0e0e4736",
+				"span.id":     "00f067aa0ba902b7",
+				"labels":      map[string]any{"user": "u1"},
+			},
+		},
+		{
+			name: "Google Cloud",
+			f:    FormatGoogleCloud,
+			want: map[string]any{
+				"severity":                        "WARNING",
+				"message":                         "slow request",
+				"time":                            "2026-10-17T14:05:06.007Z",
+				"logging.googleapis.com/insertId": "abc123",
+				"logging.googleapis.com/trace":    "4bf92f3577b34da6a3ce929d0e0e4736",
+				"logging.googleapis.com/spanId":   "00f067aa0ba902b7",
+				"user":                            "u1",
+			},
+		},
+		{
+			name: "Google Cloud project",
+			f:    NewGoogleCloudFormatter("my-project"),
+			want: map[string]any{
+				"severity":                        "WARNING",
+				"message":                         "slow request",
+				"time":                            "2026-10-17T14:05:06.007Z",
+				"logging.googleapis.com/insertId": "abc123",
+				"logging.googleapis.com/trace":    "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+				"logging.googleapis.com/spanId":   "00f067aa0ba902b7",
+				"user":                            "u1",
+			},
+		},
+		{
+			name: "Datadog",
+			f:    FormatDatadog,
+			want: map[string]any{
+				"status":      "warn",
+				"message":     "slow request",
+				"timestamp":   float64(1792245906007),
+				"code":        "abc123",
+				"dd.trace_id": "11803532876627986230",
+				"dd.span_id":  "67667974448284343",
+				"trace_id":    "4bf92f3577b34da6a3ce929d0e0e4736",
+				"span_id":     "00f067aa0ba902b7",
+				"user":        "u1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.f(presetTestMessage())
+			got := map[string]any{}
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatalf("failed to unmarshal %s: %v", b, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Formatter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoogleCloudFormatterKeepsTraceName(t *testing.T) {
+	m := presetTestMessage()
+	m.Meta["trace_id"] = "projects/other/traces/4bf92f3577b34da6a3ce929d0e0e4736"
+	got := map[string]any{}
+	if err := json.Unmarshal(NewGoogleCloudFormatter("my-project")(m), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["logging.googleapis.com/trace"] != m.Meta["trace_id"] {
+		t.Errorf("expected the full trace name to be kept. Got: %v", got["logging.googleapis.com/trace"])
+	}
+}
+
+func TestFormatECSFlattensLabels(t *testing.T) {
+	m := presetTestMessage()
+	m.Meta["http"] = Meta{"status": 500, "request.id": "r1"}
+	m.Meta["took"] = 1500 * time.Millisecond
+	got := struct {
+		Labels map[string]any `json:"labels"`
+	}{}
+	if err := json.Unmarshal(FormatECS(m), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"user": "u1", "http_status": "500", "http_request_id": "r1", "took": "1.5s"}
+	if !reflect.DeepEqual(got.Labels, want) {
+		t.Errorf("expected flat keyword labels. Got: %v, want %v", got.Labels, want)
+	}
+}
@@ -0,0 +1,231 @@
+package logr
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// prettyIndent is the indentation of the lines written beneath the first line of a message by FormatPretty
+const prettyIndent = "    "
+
+// prettyMaxDepth is how deep FormatPretty expands nested maps and structs
+const prettyMaxDepth = 8
+
+// prettyEntry is a key and value written on its own line by FormatPretty
+type prettyEntry struct {
+	key   string
+	value any
+}
+
+// FormatPretty is a Formatter for local development. The first line is the same as FormatDefault without the meta
+// data. Any further lines of the description are indented beneath it, followed by the meta data with one key per line,
+// sorted and aligned. Nested maps and structs are expanded, multi-line values such as stack traces are indented,
+// durations are rounded and integer values with keys ending in "bytes" or "size" are written as byte sizes.
+func FormatPretty(m *Message) []byte {
+	return AppendPretty(make([]byte, 0, 256), m)
+}
+
+// AppendPretty is the AppendFormatter equivalent of FormatPretty
+func AppendPretty(dst []byte, m *Message) []byte {
+	dst = appendPadded(dst, m.Time, 25)
+	dst = append(dst, " | "...)
+	dst = append(dst, m.Code...)
+	dst = append(dst, " | "...)
+	dst = append(dst, m.Type.Rune()...)
+	dst = append(dst, " | "...)
+	first, rest, _ := strings.Cut(strings.TrimRight(m.Desc, "\n"), "\n")
+	dst = appendText(dst, first)
+	dst = append(dst, '\n')
+	if rest != "" {
+		dst = appendIndentedLines(dst, rest, prettyIndent)
+	}
+	if len(m.Meta) > 0 {
+		entries := prettyMapEntries(reflect.ValueOf(map[string]any(m.Meta)))
+		dst = appendPrettyEntries(dst, entries, prettyIndent, 0, map[uintptr]bool{})
+	}
+	return dst
+}
+
+// appendPrettyEntries appends one line per entry with the keys padded to the same width. Nested values are expanded
+// up to prettyMaxDepth, and visited holds the maps and pointers being expanded, so a value containing itself is
+// written as <cycle> instead of recursing forever.
+func appendPrettyEntries(dst []byte, entries []prettyEntry, indent string, depth int, visited map[uintptr]bool) []byte {
+	width := 0
+	for _, e := range entries {
+		if n := utf8.RuneCountInString(e.key); n > width {
+			width = n
+		}
+	}
+	for _, e := range entries {
+		dst = append(dst, indent...)
+		s := ""
+		if nested, id, ok := prettyNested(e.value); ok && len(nested) > 0 {
+			switch {
+			case id != 0 && visited[id]:
+				s = "<cycle>"
+			case depth >= prettyMaxDepth:
+				s = "<max depth>"
+			default:
+				dst = appendText(dst, e.key)
+				dst = append(dst, ":\n"...)
+				if id != 0 {
+					visited[id] = true
+				}
+				dst = appendPrettyEntries(dst, nested, indent+"  ", depth+1, visited)
+				delete(visited, id)
+				continue
+			}
+		} else {
+			s = prettyValue(e.key, e.value)
+		}
+		if strings.Contains(s, "\n") {
+			dst = appendText(dst, e.key)
+			dst = append(dst, ":\n"...)
+			dst = appendIndentedLines(dst, strings.TrimRight(s, "\n"), indent+"  ")
+			continue
+		}
+		start := len(dst)
+		dst = appendPadded(dst, e.key, width)
+		dst = sanitizeFrom(dst, start)
+		dst = append(dst, " : "...)
+		dst = appendText(dst, s)
+		dst = append(dst, '\n')
+	}
+	return dst
+}
+
+// appendIndentedLines appends every line of s prefixed with indent. Line breaks are kept, as the indentation shows
+// which message the lines belong to, and anything else SanitizeText escapes is escaped.
+func appendIndentedLines(dst []byte, s, indent string) []byte {
+	for _, line := range strings.Split(s, "\n") {
+		dst = append(dst, indent...)
+		dst = appendText(dst, strings.TrimRight(line, "\r"))
+		dst = append(dst, '\n')
+	}
+	return dst
+}
+
+// prettyNested returns the entries of maps with string keys and structs, which FormatPretty expands, and the address
+// identifying the map or pointer, or 0 for a struct value. Values that describe themselves, such as errors,
+// fmt.Stringers and times, are not expanded.
+func prettyNested(v any) ([]prettyEntry, uintptr, bool) {
+	switch v.(type) {
+	case nil, error, fmt.Stringer, time.Time:
+		return nil, 0, false
+	}
+	rv := reflect.ValueOf(v)
+	var id uintptr
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, 0, false
+		}
+		if rv.Kind() == reflect.Ptr && id == 0 {
+			id = rv.Pointer()
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, 0, false
+		}
+		if id == 0 {
+			id = rv.Pointer()
+		}
+		return prettyMapEntries(rv), id, true
+	case reflect.Struct:
+		if _, ok := rv.Interface().(time.Time); ok {
+			return nil, 0, false
+		}
+		var entries []prettyEntry
+		for i := 0; i < rv.NumField(); i++ {
+			if f := rv.Type().Field(i); f.IsExported() {
+				entries = append(entries, prettyEntry{key: f.Name, value: rv.Field(i).Interface()})
+			}
+		}
+		return entries, id, true
+	}
+	return nil, 0, false
+}
+
+// prettyMapEntries returns the entries of a map with string keys in key order
+func prettyMapEntries(rv reflect.Value) []prettyEntry {
+	entries := make([]prettyEntry, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		entries = append(entries, prettyEntry{key: iter.Key().String(), value: iter.Value().Interface()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+	return entries
+}
+
+// prettyValue converts a value to a human friendly string
+func prettyValue(key string, v any) string {
+	switch t := v.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return t
+	case time.Duration:
+		return humanDuration(t)
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case error:
+		return errorText(t)
+	case fmt.Stringer:
+		return stringerText(t)
+	}
+	if isByteSizeKey(key) {
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return humanBytes(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if n := rv.Uint(); n <= 1<<63-1 {
+				return humanBytes(int64(n))
+			}
+		}
+	}
+	return fmt.Sprintf("%+v", v)
+}
+
+// isByteSizeKey reports whether a meta data key names a size in bytes
+func isByteSizeKey(key string) bool {
+	k := strings.ToLower(key)
+	return strings.HasSuffix(k, "bytes") || strings.HasSuffix(k, "size")
+}
+
+// humanDuration rounds a duration to a precision that is easy to read
+func humanDuration(d time.Duration) string {
+	switch {
+	case d < 0:
+		return "-" + humanDuration(-d)
+	case d >= time.Second:
+		d = d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		d = d.Round(time.Microsecond)
+	}
+	return d.String()
+}
+
+// humanBytes formats a number of bytes using binary units, e.g. 1.5 MiB
+func humanBytes(n int64) string {
+	if n < 1024 && n > -1024 {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	f := float64(n)
+	units := "KMGTPE"
+	i := -1
+	for (f >= 1024 || f <= -1024) && i < len(units)-1 {
+		f /= 1024
+		i++
+	}
+	return strconv.FormatFloat(f, 'f', 1, 64) + " " + units[i:i+1] + "iB"
+}
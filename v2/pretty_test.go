@@ -0,0 +1,105 @@
+package logr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatPretty(t *testing.T) {
+	type user struct {
+		ID    int
+		Name  string
+		token string
+	}
+	m := &Message{
+		Type: E,
+		Time: "Oct 17 2026 14:05:06",
+		Code: "abc123",
+		Desc: "request failed\nsecond line",
+		Meta: MetaData{
+			"latency":       1234567891 * time.Nanosecond,
+			"response_size": 1536,
+			"body_bytes":    uint64(3 << 20),
+			"count":         12,
+			"error":         errors.New("timeout"),
+			"user":          &user{ID: 42, Name: "Jane", token: "secret"},
+			"headers":       map[string]string{"Accept": "*/*", "X-Request-Id": "req-1"},
+			"stack":         "goroutine 1 [running]:\nmain.main()\n",
+		},
+	}
+	want := `Oct 17 2026 14:05:06      | abc123 | E | request failed
+    second line
+    body_bytes    : 3.0 MiB
+    count         : 12
+    error         : timeout
+    headers:
+      Accept       : */*
+      X-Request-Id : req-1
+    latency       : 1.235s
+    response_size : 1.5 KiB
+    stack:
+      goroutine 1 [running]:
+      main.main()
+    user:
+      ID   : 42
+      Name : Jane
+`
+	if got := string(FormatPretty(m)); got != want {
+		t.Errorf("FormatPretty() = \n%s\nwant\n%s", got, want)
+	}
+
+	if got, want := string(FormatPretty(&Message{Type: I, Time: "Oct 17 2026 14:05:06", Code: "abc123", Desc: "hello"})), "Oct 17 2026 14:05:06      | abc123 | I | hello\n"; got != want {
+		t.Errorf("FormatPretty() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatPrettyCyclesAndDepth(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+	loop := &node{Name: "a"}
+	loop.Next = loop
+	self := map[string]any{"id": 1}
+	self["self"] = self
+	deep := map[string]any{"leaf": true}
+	for i := 0; i < prettyMaxDepth; i++ {
+		deep = map[string]any{"d": deep}
+	}
+	m := &Message{Type: I, Time: "Oct 17 2026 14:05:06", Code: "abc123", Desc: "hello",
+		Meta: MetaData{"loop": loop, "self": self, "deep": deep}}
+
+	got := string(FormatPretty(m))
+	for _, want := range []string{
+		"    loop:\n      Name : a\n      Next : <cycle>\n",
+		"    self:\n      id   : 1\n      self : <cycle>\n",
+		"d : <max depth>\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "leaf") {
+		t.Errorf("expected nesting beyond the maximum depth to be cut off. Got:\n%s", got)
+	}
+}
+
+func TestHumanBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{n: 0, want: "0 B"},
+		{n: 1023, want: "1023 B"},
+		{n: 1024, want: "1.0 KiB"},
+		{n: 40 << 20, want: "40.0 MiB"},
+		{n: -2048, want: "-2.0 KiB"},
+	}
+	for _, tt := range tests {
+		if got := humanBytes(tt.n); got != tt.want {
+			t.Errorf("humanBytes(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+}
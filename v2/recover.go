@@ -0,0 +1,65 @@
+package logr
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+type RecoverConfig struct {
+	repanic bool
+	meta    Meta
+}
+
+type RecoverConfigModifier func(c RecoverConfig) RecoverConfig
+
+// WithRepanic creates a RecoverConfigModifier that panics again with the recovered value once it has been logged
+func WithRepanic() RecoverConfigModifier {
+	return func(c RecoverConfig) RecoverConfig {
+		c.repanic = true
+		return c
+	}
+}
+
+// WithRecoverMeta creates a RecoverConfigModifier that attaches meta data to the logged panic
+func WithRecoverMeta(meta Meta) RecoverConfigModifier {
+	return func(c RecoverConfig) RecoverConfig {
+		c.meta = meta
+		return c
+	}
+}
+
+// Recover recovers from a panic and logs it as a P message with the stack trace attached. It must be deferred
+// directly for the panic to be recovered.
+//
+//	go func() {
+//		defer logr.Recover(l)
+//		...
+//	}()
+func Recover(l Logger, configs ...RecoverConfigModifier) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	c := RecoverConfig{}
+	for _, m := range configs {
+		c = m(c)
+	}
+	if c.meta != nil {
+		l = l.With(c.meta)
+	}
+	LogRecovered(l, r)
+
+	if c.repanic {
+		panic(r)
+	}
+}
+
+// LogRecovered logs a value recovered from a panic as a P message with the stack trace of the current goroutine
+// attached, waits for it to be written and returns its code.
+func LogRecovered(l Logger, r any) string {
+	return LogAs(l.With(Meta{
+		"panic": fmt.Sprintf("%v", r),
+		"stack": string(debug.Stack()),
+	}), P, "recovered from panic:", r)
+}
@@ -0,0 +1,39 @@
+package logr
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	rbuf := &bytes.Buffer{}
+	stop := AddWriter(rbuf, WithFilter(P))
+	defer stop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer Recover(Default(), WithRecoverMeta(Meta{"worker": "TestRecover"}))
+		panic("TestRecover panic")
+	}()
+	<-done
+	errb.Reset()
+
+	b := mustReadBuffer(rbuf, t)
+	for _, s := range []string{"| P | recovered from panic: TestRecover panic |", "worker:TestRecover", "panic:TestRecover panic", "stack:goroutine"} {
+		if !bytes.Contains(b, []byte(s)) {
+			t.Errorf("expected buffer to contain '%s'. Got: %s", s, b)
+		}
+	}
+}
+
+func TestRecoverRepanic(t *testing.T) {
+	defer func() {
+		errb.Reset()
+		if r := recover(); r != "TestRecoverRepanic panic" {
+			t.Errorf("expected the recovered value to be panicked again. Got: %v", r)
+		}
+	}()
+	defer Recover(Default(), WithRepanic())
+	panic("TestRecoverRepanic panic")
+}
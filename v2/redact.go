@@ -0,0 +1,445 @@
+package logr
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// DefaultRedactedKeys are the meta data key patterns redacted by NewRedactor when no keys are configured
+var DefaultRedactedKeys = []string{
+	"*password*",
+	"*passwd*",
+	"*secret*",
+	"*token*",
+	"*api_key*",
+	"*apikey*",
+	"*private_key*",
+	"authorization",
+	"cookie",
+	"set-cookie",
+}
+
+// RedactRule replaces every match of Pattern in the description and string meta data values of a Message. If Valid
+// is set, only matches it returns true for are replaced.
+type RedactRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Valid   func(match string) bool
+}
+
+// Built-in redaction rules
+var (
+	// RuleCardNumber matches payment card numbers that pass the Luhn check and are either written in groups separated
+	// by spaces or dashes or start with the prefix of a major card network
+	RuleCardNumber = RedactRule{
+		Name:    "card",
+		Pattern: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Valid:   cardNumber,
+	}
+	// RuleJWT matches JSON web tokens
+	RuleJWT = RedactRule{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	}
+	// RuleEmail matches email addresses
+	RuleEmail = RedactRule{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	}
+	// RuleAWSAccessKey matches AWS access key IDs
+	RuleAWSAccessKey = RedactRule{
+		Name:    "aws_access_key",
+		Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`),
+	}
+	// BuiltinRedactRules are all the built-in redaction rules
+	BuiltinRedactRules = []RedactRule{RuleCardNumber, RuleJWT, RuleEmail, RuleAWSAccessKey}
+)
+
+type RedactorConfig struct {
+	keys  []string
+	rules []RedactRule
+	mask  string
+}
+
+type RedactorConfigModifier func(c RedactorConfig) RedactorConfig
+
+// WithRedactedKeys creates a RedactorConfigModifier that redacts the values of meta data keys matching the given
+// patterns, at any depth. Patterns are matched case-insensitively against the whole key, where * matches any text and
+// ? matches any single character, such as *token*. Setting keys replaces DefaultRedactedKeys.
+func WithRedactedKeys(patterns ...string) RedactorConfigModifier {
+	return func(c RedactorConfig) RedactorConfig {
+		keys := make([]string, 0, len(c.keys)+len(patterns))
+		keys = append(keys, c.keys...)
+		for _, p := range patterns {
+			keys = append(keys, strings.ToLower(p))
+		}
+		c.keys = keys
+		return c
+	}
+}
+
+// WithRedactRules creates a RedactorConfigModifier that applies the given rules, such as BuiltinRedactRules, to the
+// description and string meta data values.
+func WithRedactRules(rules ...RedactRule) RedactorConfigModifier {
+	return func(c RedactorConfig) RedactorConfig {
+		c.rules = append(append([]RedactRule{}, c.rules...), rules...)
+		return c
+	}
+}
+
+// WithMask creates a RedactorConfigModifier that sets the text redacted values are replaced with. The default is
+// [REDACTED].
+func WithMask(mask string) RedactorConfigModifier {
+	return func(c RedactorConfig) RedactorConfig {
+		c.mask = mask
+		return c
+	}
+}
+
+// Redactor masks sensitive data in messages before they are formatted
+type Redactor struct {
+	keys  []string
+	rules []RedactRule
+	mask  string
+}
+
+// NewRedactor creates a Redactor. Without WithRedactedKeys it redacts DefaultRedactedKeys.
+//
+//	SetRedactor(NewRedactor(WithRedactRules(BuiltinRedactRules...)))
+func NewRedactor(configs ...RedactorConfigModifier) *Redactor {
+	// default config
+	c := RedactorConfig{
+		mask: "[REDACTED]",
+	}
+	// apply optional extra config modifiers
+	for _, m := range configs {
+		c = m(c)
+	}
+	if c.keys == nil {
+		c = WithRedactedKeys(DefaultRedactedKeys...)(c)
+	}
+	return &Redactor{
+		keys:  c.keys,
+		rules: c.rules,
+		mask:  c.mask,
+	}
+}
+
+var globalRedactor atomic.Value
+
+// SetRedactor sets the Redactor applied to messages for every writer that does not have its own. A nil Redactor
+// disables global redaction.
+func SetRedactor(r *Redactor) {
+	globalRedactor.Store(&r)
+}
+
+// currentRedactor returns the Redactor set with SetRedactor
+func currentRedactor() *Redactor {
+	if r, ok := globalRedactor.Load().(**Redactor); ok {
+		return *r
+	}
+	return nil
+}
+
+// Redact returns m with sensitive data masked. m is returned as it is if there is nothing to redact, otherwise a copy
+// is returned and m, including its meta data, is left unchanged.
+func (r *Redactor) Redact(m *Message) *Message {
+	if r == nil {
+		return m
+	}
+	desc, descChanged := r.redactString(m.Desc)
+	meta, metaChanged := r.redactMap(m.Meta)
+	if !descChanged && !metaChanged {
+		return m
+	}
+	c := m.copy()
+	c.Desc = desc
+	if metaChanged {
+		c.Meta = MetaData(meta)
+	}
+	return c
+}
+
+// matchKey reports whether the value of key should be redacted
+func (r *Redactor) matchKey(key string) bool {
+	k := strings.ToLower(key)
+	for _, p := range r.keys {
+		if matchWildcard(p, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWildcard reports whether s matches pattern, where * matches any text, including none, and ? matches any single
+// character. Unlike path.Match, * also matches slashes.
+func matchWildcard(pattern, s string) bool {
+	// star and next are where to resume after the last *, so it can take up more of s if the rest fails to match
+	star, next := -1, 0
+	p, i := 0, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, next = p, i
+			p++
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			if pattern[p] == '?' {
+				_, size := utf8.DecodeRuneInString(s[i:])
+				i += size
+			} else {
+				i++
+			}
+			p++
+		case star >= 0:
+			_, size := utf8.DecodeRuneInString(s[next:])
+			next += size
+			p, i = star+1, next
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// redactString applies the rules to s
+func (r *Redactor) redactString(s string) (string, bool) {
+	changed := false
+	for _, rule := range r.rules {
+		if !rule.Pattern.MatchString(s) {
+			continue
+		}
+		s = rule.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if rule.Valid != nil && !rule.Valid(match) {
+				return match
+			}
+			changed = true
+			return r.mask
+		})
+	}
+	return s, changed
+}
+
+// redactMap returns a redacted copy of m, or m itself if nothing was redacted
+func (r *Redactor) redactMap(m map[string]any) (map[string]any, bool) {
+	var out map[string]any
+	for k, v := range m {
+		var nv any = r.mask
+		changed := true
+		if !r.matchKey(k) {
+			nv, changed = r.redactValue(v)
+		}
+		if !changed {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]any, len(m))
+			for ok, ov := range m {
+				out[ok] = ov
+			}
+		}
+		out[k] = nv
+	}
+	if out == nil {
+		return m, false
+	}
+	return out, true
+}
+
+// redactValue returns a redacted copy of v, or v itself if nothing was redacted
+func (r *Redactor) redactValue(v any) (any, bool) {
+	switch t := v.(type) {
+	case nil:
+		return v, false
+	case string:
+		return r.redactString(t)
+	case Meta:
+		if m, ok := r.redactMap(t); ok {
+			return Meta(m), true
+		}
+	case MetaData:
+		if m, ok := r.redactMap(t); ok {
+			return MetaData(m), true
+		}
+	case map[string]any:
+		return r.redactMap(t)
+	case map[string]string:
+		var out map[string]string
+		for k, s := range t {
+			ns, changed := r.mask, true
+			if !r.matchKey(k) {
+				ns, changed = r.redactString(s)
+			}
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = make(map[string]string, len(t))
+				for ok, os := range t {
+					out[ok] = os
+				}
+			}
+			out[k] = ns
+		}
+		if out != nil {
+			return out, true
+		}
+	case []string:
+		var out []string
+		for i, e := range t {
+			ne, changed := r.redactString(e)
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = append([]string{}, t...)
+			}
+			out[i] = ne
+		}
+		if out != nil {
+			return out, true
+		}
+	case []any:
+		var out []any
+		for i, e := range t {
+			ne, changed := r.redactValue(e)
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = append([]any{}, t...)
+			}
+			out[i] = ne
+		}
+		if out != nil {
+			return out, true
+		}
+	default:
+		if r.structHasKey(reflect.ValueOf(v), 0) {
+			// convert the struct to a map using its json encoding so the matching fields can be redacted
+			var m map[string]any
+			if b, err := json.Marshal(v); err == nil && json.Unmarshal(b, &m) == nil {
+				rm, _ := r.redactMap(m)
+				return rm, true
+			}
+			return r.mask, true
+		}
+	}
+	return v, false
+}
+
+// structHasKey reports whether a struct has a field, at any depth, whose name or json name should be redacted
+func (r *Redactor) structHasKey(rv reflect.Value, depth int) bool {
+	if depth > 8 {
+		return false
+	}
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return false
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		for i := 0; i < rv.NumField(); i++ {
+			f := rv.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := f.Name
+			if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag != "" && tag != "-" {
+				name = tag
+			}
+			if r.matchKey(name) || r.structHasKey(rv.Field(i), depth+1) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if r.structHasKey(rv.Index(i), depth+1) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			if iter.Key().Kind() == reflect.String && r.matchKey(iter.Key().String()) {
+				return true
+			}
+			if r.structHasKey(iter.Value(), depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cardNumber reports whether s looks like a payment card number rather than any other long number, such as an ID or
+// a timestamp
+func cardNumber(s string) bool {
+	if !luhn(s) {
+		return false
+	}
+	if strings.ContainsAny(s, " -") {
+		return true
+	}
+	return cardIIN(s)
+}
+
+// cardIIN reports whether the digits s start with the issuer identification number of a major card network and have
+// a length it issues: Visa, Mastercard, American Express, Discover, JCB, Diners Club or UnionPay.
+func cardIIN(s string) bool {
+	n := len(s)
+	prefix := func(digits int) int {
+		v := 0
+		for i := 0; i < digits && i < n; i++ {
+			v = v*10 + int(s[i]-'0')
+		}
+		return v
+	}
+	switch p2, p4 := prefix(2), prefix(4); {
+	case s[0] == '4':
+		return n == 13 || n == 16 || n == 19
+	case p2 >= 51 && p2 <= 55, p4 >= 2221 && p4 <= 2720:
+		return n == 16
+	case p2 == 34 || p2 == 37:
+		return n == 15
+	case p4 == 6011, p2 == 65, prefix(3) >= 644 && prefix(3) <= 649:
+		return n >= 16 && n <= 19
+	case p4 >= 3528 && p4 <= 3589:
+		return n >= 16 && n <= 19
+	case p2 == 36, p2 == 38, p2 == 39, prefix(3) >= 300 && prefix(3) <= 305:
+		return n >= 14 && n <= 19
+	case p2 == 62:
+		return n >= 16 && n <= 19
+	}
+	return false
+}
+
+// luhn reports whether the digits in s pass the Luhn checksum
+func luhn(s string) bool {
+	sum, n, double := 0, 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+		double = !double
+	}
+	return n >= 13 && sum%10 == 0
+}
@@ -0,0 +1,183 @@
+package logr
+
+import (
+	"strconv"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// sanitizeDisabled is set when SetSanitize turns sanitization off
+var sanitizeDisabled int32
+
+// SetSanitize sets whether the text formatters, FormatDefault, FormatWithColours, FormatPretty, ColourFormatter, the
+// syslog formatters and template formatters, escape control characters in descriptions and meta data with
+// SanitizeText. It is on by default, so a value containing a line break or a terminal escape sequence cannot forge log
+// lines or change the terminal reading them. JSON and logfmt output is escaped by its encoding and is not affected.
+func SetSanitize(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&sanitizeDisabled, 0)
+	} else {
+		atomic.StoreInt32(&sanitizeDisabled, 1)
+	}
+}
+
+// sanitizing reports whether the text formatters sanitize their output
+func sanitizing() bool {
+	return atomic.LoadInt32(&sanitizeDisabled) == 0
+}
+
+// SanitizeText escapes control characters, line and paragraph separators, bidirectional overrides and invalid UTF-8
+// in s, using the escapes of Go string literals such as \n, \x1b and \u2028. The result is a single line of visible
+// text. Backslashes are left as they are, so text such as Windows paths is written unchanged.
+func SanitizeText(s string) string {
+	return string(appendSanitized(make([]byte, 0, len(s)+16), s))
+}
+
+// UnsanitizeText reverses SanitizeText. As backslashes are not escaped, text that contained a sequence such as \n
+// before it was sanitized is converted as well. Backslashes that do not start one of the escapes SanitizeText writes
+// are kept.
+func UnsanitizeText(s string) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b = append(b, s[i])
+			continue
+		}
+		switch s[i+1] {
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'x', 'u':
+			n := 2
+			if s[i+1] == 'u' {
+				n = 4
+			}
+			if i+2+n > len(s) {
+				b = append(b, s[i])
+				continue
+			}
+			v, err := strconv.ParseUint(s[i+2:i+2+n], 16, 32)
+			if err != nil {
+				b = append(b, s[i])
+				continue
+			}
+			if n == 2 {
+				b = append(b, byte(v))
+			} else {
+				b = utf8.AppendRune(b, rune(v))
+			}
+			i += n
+		default:
+			b = append(b, s[i])
+			continue
+		}
+		i++
+	}
+	return string(b)
+}
+
+// appendText appends s to dst, sanitized if the text formatters sanitize their output
+func appendText(dst []byte, s string) []byte {
+	if sanitizing() {
+		return appendSanitized(dst, s)
+	}
+	return append(dst, s...)
+}
+
+// sanitizedText returns s sanitized if the text formatters sanitize their output
+func sanitizedText(s string) string {
+	if !sanitizing() || !needsSanitizing([]byte(s)) {
+		return s
+	}
+	return SanitizeText(s)
+}
+
+// sanitizeFrom sanitizes the text appended to dst from start, if the text formatters sanitize their output. It only
+// allocates when there is something to escape.
+func sanitizeFrom(dst []byte, start int) []byte {
+	if !sanitizing() || !needsSanitizing(dst[start:]) {
+		return dst
+	}
+	s := string(dst[start:])
+	return appendSanitized(dst[:start], s)
+}
+
+// needsSanitizing reports whether s contains anything SanitizeText escapes
+func needsSanitizing(s []byte) bool {
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b < ' ' || b == 0x7f {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(s[i:])
+		if escapedRune(r, size) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// appendSanitized appends s to dst with the escapes of SanitizeText
+func appendSanitized(dst []byte, s string) []byte {
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != 0x7f {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'x', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !escapedRune(r, size) {
+			i += size
+			continue
+		}
+		dst = append(dst, s[start:i]...)
+		if r == utf8.RuneError {
+			dst = append(dst, '\\', 'x', hexDigits[s[i]>>4], hexDigits[s[i]&0xF])
+		} else {
+			dst = append(dst, '\\', 'u', hexDigits[r>>12&0xF], hexDigits[r>>8&0xF], hexDigits[r>>4&0xF], hexDigits[r&0xF])
+		}
+		i += size
+		start = i
+	}
+	return append(dst, s[start:]...)
+}
+
+// escapedRune reports whether a multi-byte rune is escaped by SanitizeText: invalid UTF-8, C1 control characters,
+// which include the single character terminal control sequence introducer, line and paragraph separators and
+// bidirectional formatting characters that can reorder how text is displayed.
+func escapedRune(r rune, size int) bool {
+	switch {
+	case r == utf8.RuneError && size == 1:
+		return true
+	case r >= 0x80 && r <= 0x9f:
+		return true
+	case r == 0x2028 || r == 0x2029:
+		return true
+	case r >= 0x202a && r <= 0x202e, r >= 0x2066 && r <= 0x2069:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package logr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"plain text, ünïcode & \"quotes\"", "plain text, ünïcode & \"quotes\""},
+		{"line\nforged | E | entry", `line\nforged | E | entry`},
+		{"a\r\tb", `a\r\tb`},
+		{"\x1b[31mred\x1b[0m", `\x1b[31mred\x1b[0m`},
+		{"c1 \u009b31m", `c1 \u009b31m`},
+		{"sep\u2028bidi\u202e", `sep\u2028bidi\u202e`},
+		{`C:\Users\me`, `C:\Users\me`},
+		{"bad \xff utf8", `bad \xff utf8`},
+		{"del \x7f", `del \x7f`},
+	}
+	for _, tt := range tests {
+		got := SanitizeText(tt.s)
+		if got != tt.want {
+			t.Errorf("SanitizeText(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+		if back := UnsanitizeText(got); back != tt.s {
+			t.Errorf("UnsanitizeText(%q) = %q, want %q", got, back, tt.s)
+		}
+	}
+
+	for _, s := range []string{`trailing \`, `\q`, `\x1`, `\u12g4`, `C:\xfiles`} {
+		if back := UnsanitizeText(s); back != s {
+			t.Errorf("expected backslashes that do not start an escape to be kept. Got: %q", back)
+		}
+	}
+}
+
+func TestTextFormattersSanitize(t *testing.T) {
+	m := &Message{
+		Type: I,
+		Time: "Oct 17 2026 14:05:06",
+		Code: "abc123",
+		Desc: "login\nOct 17 2026 14:05:07      | abc124 | S | admin logged in",
+		Meta: MetaData{"user": "\x1b]0;pwned\x07", "key\n": 1},
+	}
+	for name, f := range map[string]Formatter{
+		"FormatDefault":     FormatDefault,
+		"FormatWithColours": FormatWithColours,
+		"FormatPretty":      FormatPretty,
+		"ColourFormatter":   NewColourFormatter(nil, WithColourMode(ColourAlways)).Format,
+	} {
+		out := string(f(m))
+		if name != "FormatPretty" && strings.Count(out, "\n") != 1 {
+			t.Errorf("expected %s to write a single line. Got: %q", name, out)
+		}
+		if strings.Contains(out, "\x07") || strings.Contains(out, "\x1b]") {
+			t.Errorf("expected %s to escape terminal sequences. Got: %q", name, out)
+		}
+		if !strings.Contains(out, `\x1b]0;pwned\x07`) {
+			t.Errorf("expected %s to show the escaped value. Got: %q", name, out)
+		}
+	}
+
+	SetSanitize(false)
+	defer SetSanitize(true)
+	if out := string(FormatDefault(m)); !strings.Contains(out, "\x1b]0;pwned\x07") {
+		t.Errorf("expected raw output with sanitization disabled. Got: %q", out)
+	}
+}
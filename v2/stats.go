@@ -0,0 +1,93 @@
+package logr
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// stats holds the pipeline counters updated by the listener
+var stats struct {
+	messages  uint64
+	writes    uint64
+	formatted uint64
+	reused    uint64
+	errors    uint64
+}
+
+// PipelineStats is a snapshot of the counters of the message pipeline since the process started
+type PipelineStats struct {
+	// Messages is the number of messages processed
+	Messages uint64
+	// Writes is the number of messages written to writers
+	Writes uint64
+	// Formatted is the number of times a message was formatted
+	Formatted uint64
+	// Reused is the number of writes that reused the output of a formatter shared with another writer, saving a call
+	// to the formatter
+	Reused uint64
+	// WriteErrors is the number of writes that returned an error
+	WriteErrors uint64
+	// Health is the state of the writers that deliver messages to other systems, such as NetWriter, sorted by name
+	Health []WriterHealth
+}
+
+// WriterHealth is the state of a writer that delivers messages to another system
+type WriterHealth struct {
+	// Name identifies the writer, e.g. tcp://127.0.0.1:5170
+	Name string
+	// Healthy is false while the writer is failing to deliver messages
+	Healthy bool
+	// Buffered is the number of bytes waiting to be delivered
+	Buffered int
+	// Dropped is the number of messages discarded because they could not be delivered or buffered
+	Dropped uint64
+	// Reconnects is the number of times the writer reconnected after a failure
+	Reconnects uint64
+	// LastError is the last delivery error and LastErrorTime when it happened
+	LastError     string
+	LastErrorTime time.Time
+}
+
+// HealthReporter is implemented by writers that report their WriterHealth in Stats
+type HealthReporter interface {
+	Health() WriterHealth
+}
+
+var healthReporters sync.Map
+
+// registerHealth adds h to the writers reported in Stats
+func registerHealth(h HealthReporter) {
+	healthReporters.Store(h, struct{}{})
+}
+
+// unregisterHealth removes h from the writers reported in Stats
+func unregisterHealth(h HealthReporter) {
+	healthReporters.Delete(h)
+}
+
+// Stats returns a snapshot of the pipeline counters
+func Stats() PipelineStats {
+	return PipelineStats{
+		Messages:    atomic.LoadUint64(&stats.messages),
+		Writes:      atomic.LoadUint64(&stats.writes),
+		Formatted:   atomic.LoadUint64(&stats.formatted),
+		Reused:      atomic.LoadUint64(&stats.reused),
+		WriteErrors: atomic.LoadUint64(&stats.errors),
+		Health:      health(),
+	}
+}
+
+// health returns the state of the registered writers sorted by name
+func health() []WriterHealth {
+	var list []WriterHealth
+	healthReporters.Range(func(k, _ any) bool {
+		list = append(list, k.(HealthReporter).Health())
+		return true
+	})
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
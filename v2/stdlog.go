@@ -0,0 +1,131 @@
+package logr
+
+import (
+	"bytes"
+	stdlog "log"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type StdLogConfig struct {
+	promote bool
+}
+
+type StdLogConfigModifier func(c StdLogConfig) StdLogConfig
+
+// WithErrorPromotion creates a StdLogConfigModifier that logs any line containing "error" or "panic" as an error,
+// regardless of the Type given to RedirectStdLog.
+func WithErrorPromotion() StdLogConfigModifier {
+	return func(c StdLogConfig) StdLogConfig {
+		c.promote = true
+		return c
+	}
+}
+
+// stdLogWriter is the io.Writer installed on the standard library logger
+type stdLogWriter struct {
+	mu     sync.Mutex
+	c      StdLogConfig
+	t      Type
+	l      Logger
+	flags  int
+	header *regexp.Regexp
+}
+
+// Write splits p into lines and logs each non-empty line as a separate Message. The header written by the log package
+// is stripped from the first line in case the flags or prefix were set again after the output was redirected.
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, line := range bytes.Split(p, []byte("\n")) {
+		s := strings.TrimRight(string(line), "\r")
+		if i == 0 {
+			s = w.strip(s)
+		}
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		LogAs(w.l, w.typeOf(s), s)
+	}
+	return len(p), nil
+}
+
+// strip removes the prefix and the header the log package's current flags add from a line it wrote, so text that
+// only looks like a header is kept
+func (w *stdLogWriter) strip(line string) string {
+	flags, prefix, ok := stdLogFlags()
+	if !ok {
+		return line
+	}
+	if flags&stdlog.Lmsgprefix == 0 {
+		line = strings.TrimPrefix(line, prefix)
+	}
+	if flags&(stdlog.Ldate|stdlog.Ltime|stdlog.Lmicroseconds|stdlog.Lshortfile|stdlog.Llongfile) != 0 {
+		if w.header == nil || w.flags != flags {
+			w.flags, w.header = flags, stdLogHeader(flags)
+		}
+		line = w.header.ReplaceAllString(line, "")
+	}
+	if flags&stdlog.Lmsgprefix != 0 {
+		line = strings.TrimPrefix(line, prefix)
+	}
+	return line
+}
+
+// stdLogHeader returns a pattern matching the date, time and file headers the log package adds with the given flags
+func stdLogHeader(flags int) *regexp.Regexp {
+	pattern := "^"
+	if flags&stdlog.Ldate != 0 {
+		pattern += `\d{4}/\d{2}/\d{2} `
+	}
+	if flags&(stdlog.Ltime|stdlog.Lmicroseconds) != 0 {
+		pattern += `\d{2}:\d{2}:\d{2}`
+		if flags&stdlog.Lmicroseconds != 0 {
+			pattern += `\.\d{6}`
+		}
+		pattern += " "
+	}
+	if flags&(stdlog.Lshortfile|stdlog.Llongfile) != 0 {
+		pattern += `.+?:\d+: `
+	}
+	return regexp.MustCompile(pattern)
+}
+
+// typeOf returns the Type a line should be logged as
+func (w *stdLogWriter) typeOf(line string) Type {
+	if !w.c.promote || w.t == P || w.t == E {
+		return w.t
+	}
+	lower := strings.ToLower(line)
+	if strings.Contains(lower, "error") || strings.Contains(lower, "panic") {
+		return E
+	}
+	return w.t
+}
+
+// RedirectStdLog sends the output of the standard library log package to logr as messages of the given Type with
+// the given meta data attached. The log package's prefix and flags are cleared while redirected, and stripped if they
+// are set again, so that only the message itself is logged. Setting them again requires Go 1.21 or later for them to
+// be stripped. Calling restore puts back the previous output, prefix and flags.
+func RedirectStdLog(t Type, meta Meta, configs ...StdLogConfigModifier) (restore func()) {
+	c := StdLogConfig{}
+	for _, m := range configs {
+		c = m(c)
+	}
+
+	out, flags, prefix := stdlog.Writer(), stdlog.Flags(), stdlog.Prefix()
+	stdlog.SetOutput(&stdLogWriter{
+		c: c,
+		t: t,
+		l: With(meta),
+	})
+	stdlog.SetFlags(0)
+	stdlog.SetPrefix("")
+
+	return func() {
+		stdlog.SetOutput(out)
+		stdlog.SetFlags(flags)
+		stdlog.SetPrefix(prefix)
+	}
+}
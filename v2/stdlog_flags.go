@@ -0,0 +1,11 @@
+//go:build go1.21
+
+package logr
+
+import stdlog "log"
+
+// stdLogFlags returns the flags and prefix of the log package. They can be read while the log package writes from Go
+// 1.21, when they became atomic.
+func stdLogFlags() (flags int, prefix string, ok bool) {
+	return stdlog.Flags(), stdlog.Prefix(), true
+}
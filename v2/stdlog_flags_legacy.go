@@ -0,0 +1,9 @@
+//go:build !go1.21
+
+package logr
+
+// stdLogFlags reports false, as before Go 1.21 reading the flags of the log package while it writes deadlocks. The
+// flags and prefix are cleared by RedirectStdLog, so lines are left as they are.
+func stdLogFlags() (flags int, prefix string, ok bool) {
+	return 0, "", false
+}
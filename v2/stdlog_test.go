@@ -0,0 +1,81 @@
+package logr
+
+import (
+	"bytes"
+	stdlog "log"
+	"testing"
+)
+
+func TestRedirectStdLog(t *testing.T) {
+	rbuf := &bytes.Buffer{}
+	stop := AddWriter(rbuf, WithFilter(I|E))
+	defer stop()
+
+	restore := RedirectStdLog(I, Meta{"source": "stdlog"}, WithErrorPromotion())
+	stdlog.SetFlags(stdlog.LstdFlags)
+	stdlog.Print("TestRedirectStdLog line 1\nTestRedirectStdLog line 2")
+	stdlog.Print("TestRedirectStdLog an error occurred")
+	restore()
+	Wait()
+
+	b := mustReadBuffer(rbuf, t)
+	lines := bytes.Split(bytes.TrimSpace(b), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines. Got: %s", b)
+	}
+	if !bytes.Contains(lines[0], []byte("| I | TestRedirectStdLog line 1 | map[application:logr source:stdlog]")) {
+		t.Errorf("expected first line to be an info message with meta. Got: %s", lines[0])
+	}
+	if !bytes.Contains(lines[1], []byte("| I | TestRedirectStdLog line 2")) {
+		t.Errorf("expected second line to be an info message. Got: %s", lines[1])
+	}
+	if !bytes.Contains(lines[2], []byte("| E | TestRedirectStdLog an error occurred")) {
+		t.Errorf("expected third line to be promoted to an error. Got: %s", lines[2])
+	}
+	errb.Reset()
+}
+
+func TestRedirectStdLogRestore(t *testing.T) {
+	prevOut, prevFlags, prevPrefix := stdlog.Writer(), stdlog.Flags(), stdlog.Prefix()
+	t.Cleanup(func() {
+		stdlog.SetOutput(prevOut)
+		stdlog.SetFlags(prevFlags)
+		stdlog.SetPrefix(prevPrefix)
+	})
+	out := &bytes.Buffer{}
+	stdlog.SetOutput(out)
+	stdlog.SetPrefix("prefix: ")
+
+	restore := RedirectStdLog(D, nil)
+	restore()
+
+	stdlog.Print("TestRedirectStdLogRestore")
+	if !bytes.Contains(out.Bytes(), []byte("prefix: ")) {
+		t.Errorf("expected log prefix to be restored. Got: %s", out)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("TestRedirectStdLogRestore")) {
+		t.Errorf("expected log output to be restored. Got: %s", out)
+	}
+}
+
+func TestRedirectStdLogKeepsHeaderLikeText(t *testing.T) {
+	rbuf := &bytes.Buffer{}
+	stop := AddWriter(rbuf, WithFilter(I))
+	defer stop()
+
+	restore := RedirectStdLog(I, nil)
+	stdlog.Print("2026/10/18 12:00:00 TestRedirectStdLogKeepsHeaderLikeText")
+	stdlog.SetFlags(stdlog.Ltime | stdlog.Lmsgprefix)
+	stdlog.SetPrefix("app: ")
+	stdlog.Print("main.go:12: TestRedirectStdLogKeepsHeaderLikeText")
+	restore()
+	Wait()
+
+	b := mustReadBuffer(rbuf, t)
+	if !bytes.Contains(b, []byte("| 2026/10/18 12:00:00 TestRedirectStdLogKeepsHeaderLikeText |")) {
+		t.Errorf("expected text before the message to be kept without flags. Got: %s", b)
+	}
+	if !bytes.Contains(b, []byte("| main.go:12: TestRedirectStdLogKeepsHeaderLikeText |")) {
+		t.Errorf("expected only the time and prefix to be stripped. Got: %s", b)
+	}
+}
@@ -0,0 +1,331 @@
+package logr
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// SyslogFacility is the facility part of a syslog priority
+type SyslogFacility int
+
+// Available syslog facilities
+const (
+	FacilityKern SyslogFacility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	FacilityLocal0 SyslogFacility = iota + 4
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// typeSeverityMap maps log types onto syslog severities
+var typeSeverityMap = map[Type]int{
+	P: 2, // critical
+	E: 3, // error
+	W: 4, // warning
+	S: 5, // notice
+	I: 6, // informational
+	D: 7, // debug
+}
+
+// Severity returns the syslog severity of the log Type
+func (t Type) Severity() int {
+	if s, ok := typeSeverityMap[t]; ok {
+		return s
+	}
+	return 6
+}
+
+type SyslogConfig struct {
+	facility SyslogFacility
+	appName  string
+	hostname string
+	sdID     string
+}
+
+type SyslogConfigModifier func(c SyslogConfig) SyslogConfig
+
+// WithFacility creates a SyslogConfigModifier that sets the facility of syslog messages. The default is
+// FacilityUser.
+func WithFacility(f SyslogFacility) SyslogConfigModifier {
+	return func(c SyslogConfig) SyslogConfig {
+		c.facility = f
+		return c
+	}
+}
+
+// WithAppName creates a SyslogConfigModifier that sets the app name, or tag, of syslog messages. The default is
+// the name of the running executable.
+func WithAppName(name string) SyslogConfigModifier {
+	return func(c SyslogConfig) SyslogConfig {
+		c.appName = name
+		return c
+	}
+}
+
+// WithHostname creates a SyslogConfigModifier that sets the hostname of syslog messages. The default is the
+// hostname reported by the kernel.
+func WithHostname(name string) SyslogConfigModifier {
+	return func(c SyslogConfig) SyslogConfig {
+		c.hostname = name
+		return c
+	}
+}
+
+// WithStructuredDataID creates a SyslogConfigModifier that sets the SD-ID of the RFC 5424 structured data element
+// the meta data is written to. The default is meta@32473.
+func WithStructuredDataID(id string) SyslogConfigModifier {
+	return func(c SyslogConfig) SyslogConfig {
+		c.sdID = id
+		return c
+	}
+}
+
+// newSyslogConfig creates a SyslogConfig with the defaults and the given modifiers applied
+func newSyslogConfig(configs []SyslogConfigModifier) SyslogConfig {
+	// default config
+	c := SyslogConfig{
+		facility: FacilityUser,
+		appName:  filepath.Base(os.Args[0]),
+		sdID:     "meta@32473",
+	}
+	c.hostname, _ = os.Hostname()
+	// apply optional extra config modifiers
+	for _, m := range configs {
+		c = m(c)
+	}
+	return c
+}
+
+// priority returns the syslog PRI value of a message of the given Type
+func (c SyslogConfig) priority(t Type) int {
+	return int(c.facility)*8 + t.Severity()
+}
+
+var (
+	defaultSyslog5424 = NewSyslog5424Formatter()
+	defaultSyslog3164 = NewSyslog3164Formatter()
+)
+
+// FormatSyslog5424 is a Formatter that converts a Message to an RFC 5424 syslog message using the default
+// configuration. Use NewSyslog5424Formatter to configure the facility, app name and hostname.
+func FormatSyslog5424(m *Message) []byte {
+	return defaultSyslog5424(m)
+}
+
+// FormatSyslog3164 is a Formatter that converts a Message to an RFC 3164 (BSD) syslog message using the default
+// configuration. Use NewSyslog3164Formatter to configure the facility, app name and hostname.
+func FormatSyslog3164(m *Message) []byte {
+	return defaultSyslog3164(m)
+}
+
+// NewSyslog5424Formatter creates a Formatter that converts a Message to an RFC 5424 syslog message. The Message code is
+// used as the MSGID and the meta data is written as the parameters of a single structured data element.
+func NewSyslog5424Formatter(configs ...SyslogConfigModifier) Formatter {
+	c := newSyslogConfig(configs)
+	hostname := syslogHeaderField(c.hostname, 255)
+	appName := syslogHeaderField(c.appName, 48)
+	procID := strconv.Itoa(os.Getpid())
+	sdID := syslogSDName(c.sdID)
+
+	return func(m *Message) []byte {
+		b := make([]byte, 0, 256)
+		b = append(b, '<')
+		b = strconv.AppendInt(b, int64(c.priority(m.Type)), 10)
+		b = append(b, ">1 "...)
+		b = messageTime(m).AppendFormat(b, "2006-01-02T15:04:05.000000Z07:00")
+		b = append(b, ' ')
+		b = append(b, hostname...)
+		b = append(b, ' ')
+		b = append(b, appName...)
+		b = append(b, ' ')
+		b = append(b, procID...)
+		b = append(b, ' ')
+		b = append(b, syslogHeaderField(m.Code, 32)...)
+		b = append(b, ' ')
+		if len(m.Meta) == 0 {
+			b = append(b, '-')
+		} else {
+			b = append(b, '[')
+			b = append(b, sdID...)
+			flatten(m.Meta, func(key string, value any) {
+				b = append(b, ' ')
+				b = append(b, syslogSDName(key)...)
+				b = append(b, `="`...)
+				b = appendSDParamValue(b, sanitizedText(logfmtValue(value)))
+				b = append(b, '"')
+			})
+			b = append(b, ']')
+		}
+		if m.Desc != "" {
+			b = append(b, ' ')
+			b = appendText(b, m.Desc)
+		}
+		return append(b, '\n')
+	}
+}
+
+// NewSyslog3164Formatter creates a Formatter that converts a Message to an RFC 3164 (BSD) syslog message. The app name
+// is used as the tag and the Message code and meta data are appended to the message as logfmt key=value pairs.
+func NewSyslog3164Formatter(configs ...SyslogConfigModifier) Formatter {
+	c := newSyslogConfig(configs)
+	hostname := syslogHeaderField(c.hostname, 255)
+	tag := syslogHeaderField(c.appName, 32)
+	pid := strconv.Itoa(os.Getpid())
+
+	return func(m *Message) []byte {
+		b := make([]byte, 0, 256)
+		b = append(b, '<')
+		b = strconv.AppendInt(b, int64(c.priority(m.Type)), 10)
+		b = append(b, '>')
+		b = messageTime(m).AppendFormat(b, time.Stamp)
+		b = append(b, ' ')
+		b = append(b, hostname...)
+		b = append(b, ' ')
+		b = append(b, tag...)
+		b = append(b, '[')
+		b = append(b, pid...)
+		b = append(b, "]: "...)
+		b = appendText(b, m.Desc)
+		b = append(b, ' ')
+		b = appendLogfmtPair(b, "code", m.Code)
+		flatten(m.Meta, func(key string, value any) {
+			b = append(b, ' ')
+			b = appendLogfmtPair(b, key, logfmtValue(value))
+		})
+		return append(b, '\n')
+	}
+}
+
+// syslogHeaderField converts s into a header field of at most max printable ASCII characters, or the nil value if
+// s is empty.
+func syslogHeaderField(s string, max int) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < max; i++ {
+		if s[i] > ' ' && s[i] < 127 {
+			b = append(b, s[i])
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// syslogSDName converts s into a valid SD-NAME of at most 32 printable ASCII characters, replacing the characters
+// that are not allowed with underscores.
+func syslogSDName(s string) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < 32; i++ {
+		switch c := s[i]; {
+		case c <= ' ' || c >= 127 || c == '=' || c == ']' || c == '"':
+			b = append(b, '_')
+		default:
+			b = append(b, c)
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
+
+// appendSDParamValue appends s to b with the characters that must be escaped in a PARAM-VALUE escaped
+func appendSDParamValue(b []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\\', ']':
+			b = append(b, '\\')
+		}
+		b = append(b, s[i])
+	}
+	return b
+}
+
+// SyslogWriter is an io.Writer that sends every write to a syslog server as a single message. Messages are sent as
+// datagrams over udp and unixgram, and with octet-counted framing (RFC 6587) over tcp. Datagrams are truncated to
+// 2048 bytes over udp, the size RFC 5426 says every receiver should accept, and to 8192 bytes over unixgram. It is built on a NetWriter, so
+// it connects in the background, keeps messages in memory while the server is down and reconnects with backoff. It
+// implements Flusher and io.Closer.
+type SyslogWriter struct {
+	w   *NetWriter
+	max int
+}
+
+// Maximum syslog message sizes sent as a single datagram
+const (
+	syslogMaxUDP      = 2048
+	syslogMaxUnixgram = 8192
+)
+
+// NewSyslogWriter creates a SyslogWriter that sends messages to addr over network, which must be one of udp, udp4,
+// udp6, tcp, tcp4, tcp6 or unixgram. The connection is configured like a NetWriter, e.g. WithTLS for RFC 5425, but
+// the framing is always the syslog one.
+func NewSyslogWriter(network, addr string, configs ...NetConfigModifier) (*SyslogWriter, error) {
+	framing, max := FramingNone, 0
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		framing = FramingOctetCount
+	case "udp", "udp4", "udp6":
+		max = syslogMaxUDP
+	case "unixgram":
+		max = syslogMaxUnixgram
+	default:
+		return nil, fmt.Errorf("logr: unsupported syslog network %q", network)
+	}
+	w, err := NewNetWriter(network, addr, append(configs[:len(configs):len(configs)], WithFraming(framing))...)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogWriter{w: w, max: max}, nil
+}
+
+// Write sends p as a single syslog message, without its trailing newline and truncated to the datagram size limit
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimRight(p, "\n")
+	if w.max > 0 && len(msg) > w.max {
+		n := w.max
+		for n > 0 && !utf8.RuneStart(msg[n]) {
+			n--
+		}
+		msg = msg[:n]
+	}
+	if _, err := w.w.Write(msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush implements Flusher
+func (w *SyslogWriter) Flush() error {
+	return w.w.Flush()
+}
+
+// Close sends the buffered messages and closes the connection to the syslog server
+func (w *SyslogWriter) Close() error {
+	return w.w.Close()
+}
+
+// Health implements HealthReporter
+func (w *SyslogWriter) Health() WriterHealth {
+	return w.w.Health()
+}
@@ -0,0 +1,184 @@
+package logr
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func syslogTestMessage() *Message {
+	ts := time.Date(2026, 10, 17, 14, 5, 6, 7000, time.UTC)
+	return &Message{
+		Type:      E,
+		Time:      ts.Format(TimeFormat),
+		Code:      "abc123",
+		Desc:      "disk full",
+		Timestamp: ts,
+		Meta:      MetaData{"path": `/var/"data"]`, "user": Meta{"id": 42}, "bad key=": "x"},
+	}
+}
+
+func TestFormatSyslog5424(t *testing.T) {
+	f := NewSyslog5424Formatter(WithFacility(FacilityLocal0), WithAppName("my app"), WithHostname("host1"))
+	got := string(f(syslogTestMessage()))
+	want := `<131>1 2026-10-17T14:05:06.000007Z host1 myapp ` + strconv.Itoa(os.Getpid()) + ` abc123 [meta@32473 bad_key_="x" path="/var/\"data\"\]" user.id="42"] disk full` + "\n"
+	if got != want {
+		t.Errorf("Syslog5424 Formatter() = %s, want %s", got, want)
+	}
+
+	got = string(f(&Message{Type: D, Timestamp: time.Date(2026, 10, 17, 14, 5, 6, 0, time.UTC)}))
+	want = `<135>1 2026-10-17T14:05:06.000000Z host1 myapp ` + strconv.Itoa(os.Getpid()) + " - -\n"
+	if got != want {
+		t.Errorf("Syslog5424 Formatter() = %s, want %s", got, want)
+	}
+}
+
+func TestFormatSyslog3164(t *testing.T) {
+	f := NewSyslog3164Formatter(WithFacility(FacilityDaemon), WithAppName("app"), WithHostname("host1"))
+	got := string(f(syslogTestMessage()))
+	want := `<27>Oct 17 14:05:06 host1 app[` + strconv.Itoa(os.Getpid()) + `]: disk full code=abc123 bad_key_=x path="/var/\"data\"]" user.id=42` + "\n"
+	if got != want {
+		t.Errorf("Syslog3164 Formatter() = %s, want %s", got, want)
+	}
+}
+
+func TestSyslogFormattersSanitize(t *testing.T) {
+	m := syslogTestMessage()
+	m.Desc = "disk full\n<11>Oct 17 14:05:06 host1 forged: \x1b[2J"
+	m.Meta = MetaData{"path": "/var\n<11>forged"}
+	pid := strconv.Itoa(os.Getpid())
+
+	got := string(NewSyslog5424Formatter(WithAppName("app"), WithHostname("host1"))(m))
+	want := `<11>1 2026-10-17T14:05:06.000007Z host1 app ` + pid + ` abc123 [meta@32473 path="/var\\n<11>forged"] disk full\n<11>Oct 17 14:05:06 host1 forged: \x1b[2J` + "\n"
+	if got != want {
+		t.Errorf("Syslog5424 Formatter() = %s, want %s", got, want)
+	}
+	got = string(NewSyslog3164Formatter(WithAppName("app"), WithHostname("host1"))(m))
+	want = `<11>Oct 17 14:05:06 host1 app[` + pid + `]: disk full\n<11>Oct 17 14:05:06 host1 forged: \x1b[2J code=abc123 path="/var\n<11>forged"` + "\n"
+	if got != want {
+		t.Errorf("Syslog3164 Formatter() = %s, want %s", got, want)
+	}
+}
+
+func TestSyslogWriterUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	w, err := NewSyslogWriter("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("<14>1 - - - - - - hello\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	b := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b[:n]); got != "<14>1 - - - - - - hello" {
+		t.Errorf("expected datagram without newline. Got: %q", got)
+	}
+
+	long := "<14>1 - - - - - - " + strings.Repeat("é", syslogMaxUDP)
+	if _, err := w.Write([]byte(long)); err != nil {
+		t.Fatal(err)
+	}
+	b = make([]byte, 2*len(long))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err = conn.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n > syslogMaxUDP || n < syslogMaxUDP-1 || !utf8.Valid(b[:n]) || !strings.HasPrefix(long, string(b[:n])) {
+		t.Errorf("expected the datagram to be truncated to %d bytes on a rune boundary. Got %d bytes", syslogMaxUDP, n)
+	}
+}
+
+func TestSyslogWriterTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	frames := make(chan string, 10)
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(c)
+			for {
+				s, err := r.ReadString(' ')
+				if err != nil {
+					break
+				}
+				n, _ := strconv.Atoi(strings.TrimSpace(s))
+				b := make([]byte, n)
+				if _, err := io.ReadFull(r, b); err != nil {
+					break
+				}
+				frames <- string(b)
+				// drop the connection after the first message to force a reconnect
+				if string(b) == "first message" {
+					break
+				}
+			}
+			c.Close()
+			frames <- "closed"
+		}
+	}()
+
+	w, err := NewSyslogWriter("tcp", l.Addr().String(), WithBackoff(time.Millisecond, 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Write([]byte("first message\n"))
+	if f := <-frames; f != "first message" {
+		t.Fatalf("expected the first frame. Got: %q", f)
+	}
+	<-frames
+	// wait for the writer to notice the server dropped the connection and connect again
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(time.Millisecond) {
+		if w.Health().Reconnects > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the dropped connection to be noticed")
+		}
+	}
+	w.Write([]byte("second message\n"))
+	w.Write([]byte("third message\n"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for f := range frames {
+		if f == "closed" {
+			break
+		}
+		got = append(got, f)
+	}
+	if strings.Join(got, ",") != "second message,third message" {
+		t.Errorf("expected every message to be delivered once after the reconnect. Got: %q", got)
+	}
+	if h := w.Health(); h.Reconnects != 1 || h.Dropped != 0 {
+		t.Errorf("expected a single reconnect. Got: %+v", h)
+	}
+}
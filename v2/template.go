@@ -0,0 +1,195 @@
+package logr
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// templateFuncs are the helper functions available to templates passed to NewTemplateFormatter
+var templateFuncs = template.FuncMap{
+	"pad":     templatePad,
+	"padLeft": templatePadLeft,
+	"colour":  func(t Type) string { return t.Colour() },
+	"reset":   func() string { return ColourReset },
+	"time":    func(layout string, t time.Time) string { return t.Format(layout) },
+	"logfmt":  templateLogfmt,
+}
+
+// TemplateData is the value templates passed to NewTemplateFormatter are executed with. It gives access to the
+// Message fields along with methods to select meta data.
+type TemplateData struct {
+	*Message
+	used []string
+}
+
+// Get returns the meta data value for key and marks it as used, so that it is left out of Rest
+func (d *TemplateData) Get(key string) any {
+	d.used = append(d.used, key)
+	return d.Meta[key]
+}
+
+// Rest returns the meta data that has not been selected with Get, or nil if there is none
+func (d *TemplateData) Rest() Meta {
+	if len(d.Meta) == 0 {
+		return nil
+	}
+	var rest Meta
+	for k, v := range d.Meta {
+		if d.isUsed(k) {
+			continue
+		}
+		if rest == nil {
+			rest = Meta{}
+		}
+		rest[k] = v
+	}
+	return rest
+}
+
+func (d *TemplateData) isUsed(key string) bool {
+	for _, k := range d.used {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// NewTemplateFormatter creates a Formatter from a text/template. The template is executed with a *TemplateData and
+// a newline is added to the output if the template does not end with one. The following functions are available:
+//
+//	pad n s         s padded with spaces on the right to n characters
+//	padLeft n s     s padded with spaces on the left to n characters
+//	colour t        the colour code for the Type t
+//	reset           the colour reset code
+//	time layout t   the time t formatted with layout
+//	logfmt meta     meta formatted as logfmt key=value pairs
+//
+// For example, the equivalent of FormatDefault is:
+//
+//	{{pad 25 .Time}} | {{.Code}} | {{.Type.Rune}} | {{.Desc}}{{with .Rest}} | {{.}}{{end}}
+//
+// The description and meta data are sanitized like FormatDefault unless SetSanitize turns it off. The template is
+// parsed once and the buffers used to execute it are reused between messages.
+func NewTemplateFormatter(tmpl string) (Formatter, error) {
+	t, err := template.New("logr").Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("logr: failed to parse template: %w", err)
+	}
+
+	buffers := &sync.Pool{
+		New: func() any {
+			return &bytes.Buffer{}
+		},
+	}
+	data := &sync.Pool{
+		New: func() any {
+			return &TemplateData{}
+		},
+	}
+
+	return func(m *Message) []byte {
+		b := buffers.Get().(*bytes.Buffer)
+		d := data.Get().(*TemplateData)
+		defer func() {
+			b.Reset()
+			buffers.Put(b)
+			d.Message = nil
+			d.used = d.used[:0]
+			data.Put(d)
+		}()
+
+		d.Message = templateMessage(m)
+		if err := t.Execute(b, d); err != nil {
+			fmt.Printf("failed to execute template in logr template Formatter: %v\n", err)
+		}
+		r := make([]byte, b.Len(), b.Len()+1)
+		copy(r, b.Bytes())
+		if !bytes.HasSuffix(r, []byte("\n")) {
+			r = append(r, '\n')
+		}
+		return r
+	}, nil
+}
+
+// templateMessage returns m with its description and meta data sanitized, if the text formatters sanitize their
+// output. Meta data values whose text needs escaping are replaced with the sanitized text. m is returned as it is if
+// there is nothing to escape.
+func templateMessage(m *Message) *Message {
+	if !sanitizing() {
+		return m
+	}
+	c := m
+	if desc := sanitizedText(m.Desc); desc != m.Desc {
+		c = m.copy()
+		c.Desc = desc
+	}
+	var meta MetaData
+	var text []byte
+	for k, v := range m.Meta {
+		text = appendMetaText(text[:0], v)
+		key := sanitizedText(k)
+		if key == k && !needsSanitizing(text) {
+			continue
+		}
+		if meta == nil {
+			meta = make(MetaData, len(m.Meta))
+			for mk, mv := range m.Meta {
+				meta[mk] = mv
+			}
+		}
+		delete(meta, k)
+		if needsSanitizing(text) {
+			v = SanitizeText(string(text))
+		}
+		meta[key] = v
+	}
+	if meta != nil {
+		if c == m {
+			c = m.copy()
+		}
+		c.Meta = meta
+	}
+	return c
+}
+
+// MustTemplateFormatter is like NewTemplateFormatter but panics if the template cannot be parsed
+func MustTemplateFormatter(tmpl string) Formatter {
+	f, err := NewTemplateFormatter(tmpl)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func templatePad(n int, v any) string {
+	s := fmt.Sprint(v)
+	if c := utf8.RuneCountInString(s); c < n {
+		return s + strings.Repeat(" ", n-c)
+	}
+	return s
+}
+
+func templatePadLeft(n int, v any) string {
+	s := fmt.Sprint(v)
+	if c := utf8.RuneCountInString(s); c < n {
+		return strings.Repeat(" ", n-c) + s
+	}
+	return s
+}
+
+func templateLogfmt(meta map[string]any) string {
+	var b []byte
+	flatten(meta, func(key string, value any) {
+		if len(b) > 0 {
+			b = append(b, ' ')
+		}
+		b = appendLogfmtPair(b, key, logfmtValue(value))
+	})
+	return string(b)
+}
@@ -0,0 +1,93 @@
+package logr
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewTemplateFormatter(t *testing.T) {
+	ts := time.Date(2026, 10, 17, 14, 5, 6, 0, time.UTC)
+	m := &Message{
+		Type:      I,
+		Time:      ts.Format(TimeFormat),
+		Code:      "abc123",
+		Desc:      "hello",
+		Timestamp: ts,
+		Meta:      MetaData{"user": "u1", "tenant": "t1", "size": 3},
+	}
+
+	tests := []struct {
+		name string
+		tmpl string
+		m    *Message
+		want string
+	}{
+		{
+			name: "Default layout",
+			tmpl: `{{pad 25 .Time}} | {{.Code}} | {{.Type.Rune}} | {{.Desc}}{{with .Rest}} | {{.}}{{end}}`,
+			m:    m,
+			want: "Oct 17 2026 14:05:06      | abc123 | I | hello | map[size:3 tenant:t1 user:u1]\n",
+		},
+		{
+			name: "Default layout without meta",
+			tmpl: `{{pad 25 .Time}} | {{.Code}} | {{.Type.Rune}} | {{.Desc}}{{with .Rest}} | {{.}}{{end}}`,
+			m:    &Message{Type: E, Time: m.Time, Code: "abc123", Desc: "hello"},
+			want: "Oct 17 2026 14:05:06      | abc123 | E | hello\n",
+		},
+		{
+			name: "Selected meta columns",
+			tmpl: "{{time \"15:04:05\" .Timestamp}} {{padLeft 7 .Type}} [{{pad 4 (.Get \"user\")}}] {{.Desc}} {{logfmt .Rest}}\n",
+			m:    m,
+			want: "14:05:06    info [u1  ] hello size=3 tenant=t1\n",
+		},
+		{
+			name: "Colours",
+			tmpl: `{{colour .Type}}{{.Type.Rune}}{{reset}} {{.Desc}}`,
+			m:    m,
+			want: I.Colour() + "I" + ColourReset + " hello\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewTemplateFormatter(tt.tmpl)
+			if err != nil {
+				t.Fatalf("NewTemplateFormatter() error = %v", err)
+			}
+			// run twice to check the pooled state is reset between messages
+			for i := 0; i < 2; i++ {
+				if got := string(f(tt.m)); got != tt.want {
+					t.Errorf("Formatter() = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewTemplateFormatterError(t *testing.T) {
+	if _, err := NewTemplateFormatter("{{.Desc"); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+}
+
+func TestTemplateFormatterSanitizes(t *testing.T) {
+	f := MustTemplateFormatter(`{{.Desc}} {{.Get "user"}} {{logfmt .Rest}}`)
+	m := &Message{
+		Type: I,
+		Desc: "hello\n2026 | forged | I | line",
+		Meta: MetaData{"user": "u1\x1b[2J", "err": errors.New("a\nb"), "ok\nkey": 1},
+	}
+	want := `hello\n2026 | forged | I | line u1\x1b[2J err="a\\nb" ok\nkey=1` + "\n"
+	if got := string(f(m)); got != want {
+		t.Errorf("Formatter() = %q, want %q", got, want)
+	}
+	if m.Desc != "hello\n2026 | forged | I | line" || m.Meta["user"] != "u1\x1b[2J" {
+		t.Errorf("expected the message to be left unchanged. Got: %+v", m)
+	}
+
+	SetSanitize(false)
+	defer SetSanitize(true)
+	if got := string(f(&Message{Desc: "a\nb"})); got != "a\nb <no value> \n" {
+		t.Errorf("expected no sanitizing when turned off. Got: %q", got)
+	}
+}
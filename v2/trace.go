@@ -0,0 +1,74 @@
+package logr
+
+import (
+	"context"
+	"strings"
+)
+
+// traceContext holds the W3C trace context headers of a request
+type traceContext struct {
+	parent string
+	state  string
+}
+
+// ContextWithTraceContext returns a new context with the given W3C traceparent and tracestate values stored in it for
+// TraceContextExtractor to find.
+func ContextWithTraceContext(ctx context.Context, traceparent, tracestate string) context.Context {
+	return context.WithValue(ctx, traceKey, traceContext{
+		parent: strings.TrimSpace(traceparent),
+		state:  strings.TrimSpace(tracestate),
+	})
+}
+
+// TraceContextExtractor is a ContextExtractor that parses the W3C traceparent and tracestate values stored with
+// ContextWithTraceContext into trace_id, span_id and trace_state fields. Invalid traceparent values are ignored.
+//
+//	logr.RegisterContextExtractor(logr.TraceContextExtractor)
+func TraceContextExtractor(ctx context.Context) Meta {
+	tc, ok := ctx.Value(traceKey).(traceContext)
+	if !ok {
+		return nil
+	}
+	traceID, spanID, ok := ParseTraceparent(tc.parent)
+	if !ok {
+		return nil
+	}
+	meta := Meta{"trace_id": traceID, "span_id": spanID}
+	if tc.state != "" {
+		meta.With("trace_state", tc.state)
+	}
+	return meta
+}
+
+// ParseTraceparent returns the trace ID and parent span ID of a W3C traceparent value,
+// formatted as version-traceid-spanid-flags. ok is false if the value is not valid.
+func ParseTraceparent(traceparent string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) < 4 || !isHex(parts[0], 2) || parts[0] == "ff" || !isHex(parts[3], 2) {
+		return "", "", false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", "", false
+	}
+	traceID, spanID = parts[1], parts[2]
+	if !isHex(traceID, 32) || !isHex(spanID, 16) {
+		return "", "", false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+// isHex reports whether s is made up of exactly n lowercase hex digits
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package logr
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		traceID     string
+		spanID      string
+		ok          bool
+	}{
+		{
+			name:        "Valid",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			traceID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+			spanID:      "00f067aa0ba902b7",
+			ok:          true,
+		},
+		{
+			name:        "Future version with extra fields",
+			traceparent: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			traceID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+			spanID:      "00f067aa0ba902b7",
+			ok:          true,
+		},
+		{
+			name:        "Zero trace ID",
+			traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		},
+		{
+			name:        "Short span ID",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902-01",
+		},
+		{
+			name:        "Invalid version",
+			traceparent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		{
+			name:        "Uppercase hex",
+			traceparent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01",
+		},
+		{
+			name:        "Empty",
+			traceparent: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traceID, spanID, ok := ParseTraceparent(tt.traceparent)
+			if traceID != tt.traceID || spanID != tt.spanID || ok != tt.ok {
+				t.Errorf("ParseTraceparent() = %v, %v, %v, want %v, %v, %v", traceID, spanID, ok, tt.traceID, tt.spanID, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCtx(t *testing.T) {
+	defer RegisterContextExtractor(TraceContextExtractor)()
+
+	ctx := ContextWithTraceContext(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "vendor=value")
+	ctx = ContextWithMeta(ctx, Meta{"tenant": "t1"})
+	With(Meta{"test": "TestCtx"}).(ContextLogger).InfoCtx(ctx, "TestCtx message")
+	Wait()
+
+	b := mustReadBuffer(buf, t)
+	for _, s := range []string{"test:TestCtx", "tenant:t1", "trace_id:4bf92f3577b34da6a3ce929d0e0e4736", "span_id:00f067aa0ba902b7", "trace_state:vendor=value"} {
+		if !bytes.Contains(b, []byte(s)) {
+			t.Errorf("expected buffer to contain '%s'. Got: %s", s, b)
+		}
+	}
+}
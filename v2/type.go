@@ -75,6 +75,16 @@ func (t Type) String() string {
 	return "unknown"
 }
 
+// nameToType returns the Type with the given descriptive string, as returned by Type.String
+func nameToType(name string) (Type, bool) {
+	for t, s := range typeStringMap {
+		if s == name {
+			return t, true
+		}
+	}
+	return None, false
+}
+
 // MashalJSON implements json.Marshaller
 func (t Type) MarshalJSON() ([]byte, error) {
 	return []byte(fmt.Sprintf("\"%v\"", t.String())), nil